@@ -1,34 +1,114 @@
 package k9amqp
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type AmqpClient struct {
+	key         string
+	fingerprint string
+	scope       string
 	amqpOptions AmqpOptions
 	poolOptions PoolOptions
-	connections []*amqp.Connection
-	channels    AmqpPool
+	tlsConfig   *tls.Config
+	selector    *nodeSelector
+	channels    *AmqpPool
+	topology    topology
+	events      chan connEvent
+	blocked     map[string]*atomic.Int64
+	returns     returnStats
+	done        chan struct{}
+	closed      sync.Once
+	ready       chan struct{}
+	openErr     error
 }
 
-type AmqpPool struct {
-	connections []*amqp.Connection
-	pool        chan *amqp.Channel
-	mutex       sync.Mutex
-	nextId      int
-	connIdx     int
+func (opt *AmqpOptions) init() error {
+	if len(opt.endpoints) > 0 {
+		return nil
+	}
+	if err := opt.initTuning(); err != nil {
+		return err
+	}
+	uris := opt.URIs
+	if opt.URI != "" {
+		uris = append([]string{opt.URI}, uris...)
+	}
+	if len(uris) == 0 && len(opt.Nodes) == 0 {
+		opt.defaults()
+		uris = []string{opt.uri(opt.Host, opt.Port)}
+	}
+	for _, rawURI := range uris {
+		endpoint, err := newAmqpEndpoint(rawURI)
+		if err != nil {
+			return err
+		}
+		opt.endpoints = append(opt.endpoints, endpoint)
+	}
+	for _, node := range opt.Nodes {
+		rawURI := node.URI
+		if rawURI == "" {
+			opt.defaults()
+			rawURI = opt.uri(node.Host, node.Port)
+		}
+		endpoint, err := newAmqpEndpoint(rawURI)
+		if err != nil {
+			return err
+		}
+		if node.Weight > 0 {
+			endpoint.weight = node.Weight
+		}
+		opt.endpoints = append(opt.endpoints, endpoint)
+	}
+	if opt.TLS.Enabled {
+		for _, endpoint := range opt.endpoints {
+			if endpoint.uri.Scheme != "amqps" {
+				return fmt.Errorf("tls is enabled, but uri '%s' is not amqps", endpoint.String())
+			}
+		}
+	}
+	first := opt.endpoints[0].uri
+	opt.Host, opt.Port, opt.Vhost = first.Host, first.Port, first.Vhost
+	opt.Username, opt.Password = first.Username, first.Password
+	return nil
 }
 
-func (opt *AmqpOptions) init() {
+// uri builds AMQP URI of a node from discrete options.
+func (opt *AmqpOptions) uri(host string, port int) string {
+	if host == "" {
+		host = opt.Host
+	}
+	if port == 0 {
+		port = opt.Port
+	}
+	uri := amqp.URI{
+		Scheme:   opt.scheme(),
+		Host:     host,
+		Port:     port,
+		Username: opt.Username,
+		Password: opt.Password,
+		Vhost:    opt.Vhost,
+	}
+	return uri.String()
+}
+
+func (opt *AmqpOptions) defaults() {
 	if opt.Host == "" {
 		opt.Host = "localhost"
 	}
 	if opt.Port == 0 {
-		opt.Port = 5672
+		if opt.TLS.Enabled {
+			opt.Port = 5671
+		} else {
+			opt.Port = 5672
+		}
 	}
 	if opt.Vhost == "" {
 		opt.Vhost = "/"
@@ -41,85 +121,106 @@ func (opt *AmqpOptions) init() {
 	}
 }
 
-func (opt *PoolOptions) init() {
-	if opt.ChannelsPerConn <= 0 {
-		opt.ChannelsPerConn = 2
-	}
-	if opt.ChannelsCacheSize < 0 {
-		opt.ChannelsCacheSize = 1
+func (opt *AmqpOptions) scheme() string {
+	if opt.TLS.Enabled {
+		return "amqps"
 	}
+	return "amqp"
 }
 
-func (p *AmqpPool) get() (*amqp.Channel, error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	select {
-	case channel := <-p.pool:
-		if channel.IsClosed() {
-			slog.Info("channel from pool is closed, creating new one")
-			return p.channel()
-		}
-		return channel, nil
-	default:
-		slog.Info("no available channel in pool, creating new one")
-		return p.channel()
+// init validates the client options and fills their defaults, no connection is opened.
+func (amqpClient *AmqpClient) init() error {
+	if err := amqpClient.amqpOptions.init(); err != nil {
+		return err
 	}
-}
-
-func (p *AmqpPool) channel() (*amqp.Channel, error) {
-	p.nextId++
-	p.connIdx = ((p.connIdx + 1) % len(p.connections))
-	channel, err := p.connections[p.connIdx].Channel()
-	if err != nil {
-		return nil, err
+	if err := amqpClient.poolOptions.init(); err != nil {
+		return err
 	}
-	return channel, nil
-}
-
-func (p *AmqpPool) put(channel *amqp.Channel, amqpError error) error {
-	if channel.IsClosed() {
-		slog.Warn("blow channel after error")
-		return nil
+	if err := amqpClient.poolOptions.Recovery.init(); err != nil {
+		return err
 	}
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	select {
-	case p.pool <- channel:
-		return nil
-	default:
-		slog.Info("pool is full, closing channel")
-		return channel.Close()
+	tlsConfig, err := amqpClient.amqpOptions.TLS.config()
+	if err != nil {
+		return err
 	}
-}
-
-func (amqpClient *AmqpClient) init() error {
-	amqpClient.amqpOptions.init()
-	amqpClient.poolOptions.init()
+	amqpClient.tlsConfig = tlsConfig
 	if amqpClient.poolOptions.ChannelsPerConn == 0 {
 		amqpClient.poolOptions.ChannelsPerConn = 2
 	}
-	var connSize = amqpClient.poolOptions.ChannelsCacheSize / amqpClient.poolOptions.ChannelsPerConn
+	amqpClient.done = make(chan struct{})
+	return nil
+}
+
+// open opens the pool connections of initialized client.
+func (amqpClient *AmqpClient) open() error {
+	var connSize = amqpClient.poolOptions.Connections
+	if connSize <= 0 {
+		connSize = amqpClient.poolOptions.ChannelsCacheSize / amqpClient.poolOptions.ChannelsPerConn
+	}
 	if connSize <= 0 {
 		connSize = 1
 	}
-	var connections = make([]*amqp.Connection, connSize)
+	selector, err := newNodeSelector(amqpClient.poolOptions.Distribution, amqpClient.amqpOptions.endpoints)
+	if err != nil {
+		return err
+	}
+	amqpClient.selector = selector
+	amqpClient.events = make(chan connEvent, 1024)
+	amqpClient.blocked = newBlockedCounts(amqpClient.amqpOptions.endpoints)
+	if amqpClient.dedicated() {
+		// VUs open their own connections on first use
+		amqpClient.channels = newAmqpPool(nil, amqpClient.poolOptions, amqpClient.amqpOptions.endpoints)
+		return nil
+	}
+	var unreachable = make(map[int]bool)
+	var connections = make([]*amqpConnection, connSize)
 	for idx := range connSize {
-		conn, err := amqpClient.Connect()
+		conn, err := amqpClient.connectNode(selector.candidates(idx), unreachable, amqpClient.scope)
 		if err != nil {
 			return err
 		}
+		slog.Info("pool connection opened", "connection", idx, "endpoint", conn.endpoint.String())
 		connections[idx] = conn
 	}
-	amqpClient.connections = connections
-	amqpClient.channels = AmqpPool{
-		connections: connections,
-		pool:        make(chan *amqp.Channel, amqpClient.poolOptions.ChannelsCacheSize)}
+	amqpClient.channels = newAmqpPool(connections, amqpClient.poolOptions, amqpClient.amqpOptions.endpoints)
+	amqpClient.channels.returned = amqpClient.returned
+	for idx, conn := range connections {
+		amqpClient.watch(idx, conn)
+	}
+	if err := amqpClient.channels.warmUp(amqpClient.poolOptions.WarmUp); err != nil {
+		return err
+	}
+	go amqpClient.maintain()
 	return nil
 }
 
+// connectNode dials candidate nodes in order skipping nodes which were unreachable before.
+func (amqpClient *AmqpClient) connectNode(candidates []int, unreachable map[int]bool, scope string) (*amqpConnection, error) {
+	var err error = errors.New("no reachable amqp node")
+	for _, idx := range candidates {
+		if unreachable[idx] {
+			continue
+		}
+		endpoint := amqpClient.amqpOptions.endpoints[idx]
+		var conn *amqp.Connection
+		conn, err = amqpClient.dial(endpoint, scope)
+		if err == nil {
+			pooled := &amqpConnection{Connection: conn, endpoint: endpoint}
+			amqpClient.watchBlocked(pooled)
+			return pooled, nil
+		}
+		slog.Warn("amqp node unreachable, skipping", "endpoint", endpoint.String(), "error", err)
+		unreachable[idx] = true
+	}
+	return nil, err
+}
+
 func (amqpClient *AmqpClient) close() error {
 	var closeErr error
-	for _, conn := range amqpClient.connections {
+	amqpClient.closed.Do(func() {
+		close(amqpClient.done)
+	})
+	for _, conn := range amqpClient.channels.conns() {
 		err := conn.Close()
 		if closeErr == nil && err != nil {
 			closeErr = err
@@ -131,16 +232,24 @@ func (amqpClient *AmqpClient) close() error {
 	return closeErr
 }
 
-func (amqpClient *AmqpClient) Connect() (*amqp.Connection, error) {
-	amqpConfig := amqp.Config{
-		Vhost:      amqpClient.amqpOptions.Vhost,
-		Properties: amqp.NewConnectionProperties(),
+// Connect dials the endpoints in order and returns the first established connection.
+func (amqpClient *AmqpClient) Connect(scope string) (*amqp.Connection, error) {
+	var err error
+	for _, endpoint := range amqpClient.amqpOptions.endpoints {
+		var conn *amqp.Connection
+		conn, err = amqpClient.dial(endpoint, scope)
+		if err == nil {
+			return conn, nil
+		}
+		slog.Error(err.Error(), "endpoint", endpoint.String())
 	}
-	var amqpUrl = fmt.Sprintf("amqp://%s:%s@%s:%d", amqpClient.amqpOptions.Username, amqpClient.amqpOptions.Password, amqpClient.amqpOptions.Host, amqpClient.amqpOptions.Port)
-	conn, err := amqp.DialConfig(amqpUrl, amqpConfig)
+	return nil, err
+}
+
+func (amqpClient *AmqpClient) dial(endpoint amqpEndpoint, scope string) (*amqp.Connection, error) {
+	amqpConfig, err := amqpClient.config(endpoint, scope)
 	if err != nil {
-		slog.Error(err.Error())
 		return nil, err
 	}
-	return conn, nil
+	return amqp.DialConfig(endpoint.rawURI, amqpConfig)
 }
@@ -0,0 +1,61 @@
+package k9amqp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AuthFactory creates SASL authentication for a node, uri holds the node credentials.
+type AuthFactory func(uri amqp.URI, opts AuthOptions) (amqp.Authentication, error)
+
+var (
+	authMechanisms = map[string]AuthFactory{
+		"PLAIN": func(uri amqp.URI, _ AuthOptions) (amqp.Authentication, error) {
+			return uri.PlainAuth(), nil
+		},
+		"AMQPLAIN": func(uri amqp.URI, _ AuthOptions) (amqp.Authentication, error) {
+			return uri.AMQPlainAuth(), nil
+		},
+		"EXTERNAL": func(amqp.URI, AuthOptions) (amqp.Authentication, error) {
+			return &amqp.ExternalAuth{}, nil
+		},
+	}
+	authMechanismsMutex sync.RWMutex
+)
+
+// RegisterAuthMechanism makes custom SASL mechanism available to auth.mechanism option,
+// it's meant to be called from init() of a Go extension built together with this one.
+func RegisterAuthMechanism(mechanism string, factory AuthFactory) {
+	authMechanismsMutex.Lock()
+	defer authMechanismsMutex.Unlock()
+	authMechanisms[strings.ToUpper(mechanism)] = factory
+}
+
+func authMechanism(mechanism string) (AuthFactory, error) {
+	authMechanismsMutex.RLock()
+	defer authMechanismsMutex.RUnlock()
+	factory, ok := authMechanisms[strings.ToUpper(mechanism)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported auth mechanism '%s'", mechanism)
+	}
+	return factory, nil
+}
+
+// sasl returns nil when no mechanism is configured, amqp.DialConfig then uses URI auth_mechanism or PLAIN.
+func (opt *AuthOptions) sasl(uri amqp.URI) ([]amqp.Authentication, error) {
+	if opt.Mechanism == "" {
+		return nil, nil
+	}
+	factory, err := authMechanism(opt.Mechanism)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := factory(uri, *opt)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s authentication: %w", opt.Mechanism, err)
+	}
+	return []amqp.Authentication{auth}, nil
+}
@@ -0,0 +1,162 @@
+package k9amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errBatchAborted = errors.New("amqp batch publish aborted")
+
+// PublishBatch publishes all messages on one pooled channel, with confirms it waits for all of them.
+func (client *Client) PublishBatch(opts PublishOptions, messages []BatchMessage) (AmqpBatchResponse, error) {
+	var err error
+	if err = opts.init(); err != nil {
+		return AmqpBatchResponse{Error: true, ErrorMessage: err.Error(), Failed: len(messages)}, err
+	}
+	channel, err := client.acquire()
+	if err != nil {
+		slog.Error("unable to get amqp channel")
+		return AmqpBatchResponse{Error: true, ErrorMessage: err.Error(), Failed: len(messages)}, err
+	}
+	defer func() {
+		client.releasePublished(channel, err)
+	}()
+	async := client.amqpClient.poolOptions.AsyncConfirm
+	confirm := opts.Confirm || client.amqpClient.poolOptions.Confirm || async
+	if confirm {
+		err = channel.confirmMode()
+	}
+	var tracker *confirmTracker
+	if async {
+		tracker = channel.tracker(client.amqpClient.poolOptions.ConfirmWindow)
+	}
+	results := make([]AmqpProduceResponse, len(messages))
+	confirmations := make([]*amqp.DeferredConfirmation, len(messages))
+	sentAt := make([]time.Time, len(messages))
+	returnIDs := make([]uint64, len(messages))
+	awaitReturns := opts.Mandatory && confirm && !async && channel.returns != nil
+	for idx, message := range messages {
+		if err != nil {
+			results[idx] = AmqpProduceResponse{Error: true, ErrorMessage: fmt.Errorf("%w: %w", errBatchAborted, err).Error()}
+			continue
+		}
+		msgOpts := message.options(opts)
+		publishing := message.Publishing
+		if awaitReturns {
+			publishing, returnIDs[idx], _ = channel.awaitReturn(publishing)
+		}
+		if async {
+			if err = tracker.reserve(client.context(), opts.timeout); err != nil {
+				results[idx] = AmqpProduceResponse{Error: true, ErrorMessage: err.Error()}
+				continue
+			}
+		}
+		sentAt[idx] = time.Now()
+		var confirmation *amqp.DeferredConfirmation
+		if _, confirmation, err = client.publish(channel, msgOpts, publishing); err != nil {
+			if async {
+				tracker.release()
+			}
+			results[idx] = AmqpProduceResponse{Error: true, ErrorMessage: err.Error()}
+			continue
+		}
+		if async {
+			go tracker.await(confirmation, sentAt[idx], client.k9amqp.confirmReporter(channel.endpoint, msgOpts))
+		} else {
+			confirmations[idx] = confirmation
+		}
+	}
+	var confirmLatencies []time.Duration
+	if confirm && !async {
+		confirmLatencies = client.waitConfirms(confirmations, sentAt, results, opts)
+	}
+	if awaitReturns {
+		client.matchReturns(channel, returnIDs, results)
+	}
+	response := AmqpBatchResponse{Results: results}
+	for _, result := range results {
+		switch {
+		case result.Error:
+			response.Failed++
+		case result.Confirmed && !result.Acked:
+			response.Sent++
+			response.Nacked++
+		default:
+			response.Sent++
+		}
+		if result.Returned {
+			response.Returned++
+		}
+	}
+	if err != nil {
+		response.Error, response.ErrorMessage = true, err.Error()
+	}
+	if metricsErr := client.k9amqp.reportBatchMetrics(channel.endpoint, opts, response, confirmLatencies); metricsErr != nil {
+		slog.Error("failed to report publish metrics", "error", metricsErr)
+	}
+	return response, err
+}
+
+// waitConfirms waits for confirms of the published messages until publish timeout for the whole batch,
+// messages not confirmed in time are marked failed.
+func (client *Client) waitConfirms(confirmations []*amqp.DeferredConfirmation, sentAt []time.Time, results []AmqpProduceResponse, opts PublishOptions) []time.Duration {
+	ctx := client.context()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	latencies := make([]time.Duration, 0, len(confirmations))
+	for idx, confirmation := range confirmations {
+		if confirmation == nil {
+			continue
+		}
+		acked, err := confirmation.WaitContext(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%w after %s", errConfirmTimeout, opts.Timeout)
+		}
+		if err != nil {
+			results[idx] = AmqpProduceResponse{Error: true, ErrorMessage: err.Error()}
+			continue
+		}
+		latencies = append(latencies, time.Since(sentAt[idx]))
+		results[idx] = AmqpProduceResponse{Confirmed: true, Acked: acked}
+	}
+	return latencies
+}
+
+// matchReturns marks confirmed messages of the batch returned by broker.
+func (client *Client) matchReturns(channel *amqpChannel, returnIDs []uint64, results []AmqpProduceResponse) {
+	var confirmed, failed []uint64
+	var confirmedIdx []int
+	for idx, result := range results {
+		switch {
+		case returnIDs[idx] == 0:
+		case result.Confirmed:
+			confirmed = append(confirmed, returnIDs[idx])
+			confirmedIdx = append(confirmedIdx, idx)
+		default:
+			failed = append(failed, returnIDs[idx])
+		}
+	}
+	channel.returns.forget(failed...)
+	for idx, returned := range channel.returns.wasReturned(confirmed...) {
+		results[confirmedIdx[idx]].Returned = returned
+	}
+}
+
+// options returns batch publish options with routing of the message.
+func (message BatchMessage) options(opts PublishOptions) PublishOptions {
+	if message.Exchange != nil {
+		opts.Exchange = *message.Exchange
+	}
+	if message.Key != nil {
+		opts.Key = *message.Key
+	}
+	return opts
+}
@@ -0,0 +1,117 @@
+package k9amqp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.k6.io/k6/v2/metrics"
+)
+
+var (
+	errBlocked        = errors.New("amqp connection blocked by broker")
+	errPublishTimeout = errors.New("amqp publish timed out")
+)
+
+// blockState tracks connection.blocked notification of a connection, unblocked is nil
+// when the connection is not blocked and is closed once the broker unblocks it.
+type blockState struct {
+	mutex     sync.Mutex
+	reason    string
+	since     time.Time
+	unblocked chan struct{}
+}
+
+func newBlockedCounts(endpoints []amqpEndpoint) map[string]*atomic.Int64 {
+	counts := make(map[string]*atomic.Int64, len(endpoints))
+	for _, endpoint := range endpoints {
+		counts[endpoint.String()] = &atomic.Int64{}
+	}
+	return counts
+}
+
+// watchBlocked follows resource alarms of the connection until it's closed.
+func (amqpClient *AmqpClient) watchBlocked(conn *amqpConnection) {
+	blockings := conn.NotifyBlocked(make(chan amqp.Blocking, 1))
+	go func() {
+		for blocking := range blockings {
+			if blocking.Active {
+				amqpClient.block(conn, blocking.Reason)
+			} else {
+				amqpClient.unblock(conn)
+			}
+		}
+		amqpClient.unblock(conn)
+	}()
+}
+
+func (amqpClient *AmqpClient) block(conn *amqpConnection, reason string) {
+	conn.block.mutex.Lock()
+	defer conn.block.mutex.Unlock()
+	if conn.block.unblocked != nil {
+		return
+	}
+	conn.block.reason, conn.block.since = reason, time.Now()
+	conn.block.unblocked = make(chan struct{})
+	blocked := amqpClient.blockedCount(conn.endpoint).Add(1)
+	slog.Warn("amqp connection blocked by broker", "endpoint", conn.endpoint.String(), "reason", reason)
+	amqpClient.send(connEvent{kind: connBlocked, endpoint: conn.endpoint, time: time.Now(), value: float64(blocked), reason: reason})
+}
+
+func (amqpClient *AmqpClient) unblock(conn *amqpConnection) {
+	conn.block.mutex.Lock()
+	defer conn.block.mutex.Unlock()
+	if conn.block.unblocked == nil {
+		return
+	}
+	close(conn.block.unblocked)
+	conn.block.unblocked = nil
+	duration := time.Since(conn.block.since)
+	blocked := amqpClient.blockedCount(conn.endpoint).Add(-1)
+	slog.Info("amqp connection unblocked", "endpoint", conn.endpoint.String(), "reason", conn.block.reason, "duration", duration)
+	amqpClient.send(connEvent{kind: connBlocked, endpoint: conn.endpoint, time: time.Now(), value: float64(blocked), reason: conn.block.reason})
+	amqpClient.send(connEvent{
+		kind:     connBlockedTime,
+		endpoint: conn.endpoint,
+		time:     time.Now(),
+		value:    metrics.D(duration),
+		reason:   conn.block.reason,
+	})
+}
+
+func (amqpClient *AmqpClient) blockedCount(endpoint amqpEndpoint) *atomic.Int64 {
+	if count, ok := amqpClient.blocked[endpoint.String()]; ok {
+		return count
+	}
+	return &atomic.Int64{}
+}
+
+// blockedErr returns errBlocked with the alarm reason when the connection is blocked.
+func (conn *amqpConnection) blockedErr() error {
+	conn.block.mutex.Lock()
+	defer conn.block.mutex.Unlock()
+	if conn.block.unblocked == nil {
+		return nil
+	}
+	return fmt.Errorf("%w (%s)", errBlocked, conn.block.reason)
+}
+
+// waitUnblocked waits until blocked connection is unblocked or deadline expires.
+func (conn *amqpConnection) waitUnblocked(deadline <-chan time.Time) error {
+	conn.block.mutex.Lock()
+	unblocked, reason := conn.block.unblocked, conn.block.reason
+	conn.block.mutex.Unlock()
+	if unblocked == nil {
+		return nil
+	}
+	select {
+	case <-unblocked:
+		return nil
+	case <-deadline:
+		return fmt.Errorf("%w (%s)", errBlocked, reason)
+	}
+}
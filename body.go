@@ -0,0 +1,224 @@
+package k9amqp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+)
+
+const (
+	BodyKindBytes = "bytes"
+	BodyKindASCII = "ascii"
+	BodyKindFile  = "file"
+
+	SizeFixed     = "fixed"
+	SizeUniform   = "uniform"
+	SizeNormal    = "normal"
+	SizeHistogram = "histogram"
+
+	// bodySourceMargin is extra generated content random payloads are sliced from.
+	bodySourceMargin = 64 * 1024
+)
+
+// BodyGenerator produces message bodies in Go. Random payloads are windows of content generated once,
+// so no body is allocated per message.
+type BodyGenerator struct {
+	mutex  sync.Mutex
+	rand   *rand.Rand
+	source []byte
+	offset bool
+	sizes  sizeDistribution
+}
+
+type sizeDistribution struct {
+	kind       string
+	size       int
+	min, max   int
+	mean       float64
+	stdDev     float64
+	sizes      []int
+	cumulative []float64
+}
+
+func (k9amqp *K9amqp) XBodyGenerator(call sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
+	opts := new(BodyOptions)
+	if len(call.Arguments) >= 1 {
+		if err := rt.ExportTo(call.Arguments[0], opts); err != nil {
+			panic(rt.NewTypeError("failed to export bodyOptions: %v", err))
+		}
+	}
+	generator, err := newBodyGenerator(*opts)
+	if err != nil {
+		panic(rt.NewGoError(err))
+	}
+	return rt.ToValue(generator).ToObject(rt)
+}
+
+func newBodyGenerator(opts BodyOptions) (*BodyGenerator, error) {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	sizes, err := newSizeDistribution(opts)
+	if err != nil {
+		return nil, err
+	}
+	generator := &BodyGenerator{rand: rand.New(rand.NewSource(seed)), sizes: sizes}
+	switch opts.Kind {
+	case "", BodyKindBytes:
+		generator.source = make([]byte, sizes.max+bodySourceMargin)
+		generator.rand.Read(generator.source)
+		generator.offset = true
+	case BodyKindASCII:
+		generator.source = make([]byte, sizes.max+bodySourceMargin)
+		for idx := range generator.source {
+			generator.source[idx] = charset[generator.rand.Intn(len(charset))]
+		}
+		generator.offset = true
+	case BodyKindFile:
+		content, err := os.ReadFile(opts.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body file: %w", err)
+		}
+		if len(content) == 0 {
+			return nil, errors.New("body file is empty")
+		}
+		if opts.Size == 0 && opts.Distribution == "" {
+			generator.sizes = sizeDistribution{kind: SizeFixed, size: len(content), max: len(content)}
+		}
+		generator.source = content
+		for len(generator.source) < generator.sizes.max {
+			generator.source = append(generator.source, content...)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported body kind '%s'", opts.Kind)
+	}
+	return generator, nil
+}
+
+func newSizeDistribution(opts BodyOptions) (sizeDistribution, error) {
+	sizes := sizeDistribution{kind: opts.Distribution, size: opts.Size, min: opts.Min, max: opts.Max, mean: opts.Mean, stdDev: opts.StdDev}
+	switch opts.Distribution {
+	case "", SizeFixed:
+		sizes.kind = SizeFixed
+		if opts.Size < 0 {
+			return sizes, errors.New("body size must not be negative")
+		}
+		sizes.max = opts.Size
+	case SizeUniform:
+		if opts.Min < 0 || opts.Max < opts.Min {
+			return sizes, fmt.Errorf("invalid uniform body size range %d-%d", opts.Min, opts.Max)
+		}
+	case SizeNormal:
+		if opts.Mean <= 0 || opts.StdDev < 0 {
+			return sizes, errors.New("normal body size requires positive mean and non negative std_dev")
+		}
+		if sizes.max == 0 {
+			sizes.max = int(math.Ceil(opts.Mean + 4*opts.StdDev))
+		}
+		if sizes.min < 0 || sizes.max < sizes.min {
+			return sizes, fmt.Errorf("invalid normal body size range %d-%d", sizes.min, sizes.max)
+		}
+	case SizeHistogram:
+		var err error
+		if sizes.sizes, sizes.cumulative, err = readHistogram(opts.Histogram); err != nil {
+			return sizes, err
+		}
+		sizes.max = slices.Max(sizes.sizes)
+	default:
+		return sizes, fmt.Errorf("unsupported body size distribution '%s'", opts.Distribution)
+	}
+	return sizes, nil
+}
+
+// readHistogram reads lines of body size and its weight separated by whitespace or comma,
+// empty lines and lines starting with '#' are skipped.
+func readHistogram(path string) ([]int, []float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read body size histogram: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			slog.Error("failed to close body size histogram", "error", closeErr)
+		}
+	}()
+	var sizes []int
+	var cumulative []float64
+	var total float64
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("invalid body size histogram line %d: '%s'", line, text)
+		}
+		size, err := strconv.Atoi(fields[0])
+		if err != nil || size < 0 {
+			return nil, nil, fmt.Errorf("invalid body size on histogram line %d: '%s'", line, fields[0])
+		}
+		weight, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || weight < 0 {
+			return nil, nil, fmt.Errorf("invalid weight on histogram line %d: '%s'", line, fields[1])
+		}
+		total += weight
+		sizes = append(sizes, size)
+		cumulative = append(cumulative, total)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read body size histogram: %w", err)
+	}
+	if total == 0 {
+		return nil, nil, errors.New("body size histogram has no weighted sizes")
+	}
+	return sizes, cumulative, nil
+}
+
+func (sizes *sizeDistribution) next(r *rand.Rand) int {
+	switch sizes.kind {
+	case SizeUniform:
+		return sizes.min + r.Intn(sizes.max-sizes.min+1)
+	case SizeNormal:
+		size := int(math.Round(r.NormFloat64()*sizes.stdDev + sizes.mean))
+		return min(max(size, sizes.min), sizes.max)
+	case SizeHistogram:
+		weight := r.Float64() * sizes.cumulative[len(sizes.cumulative)-1]
+		idx, _ := slices.BinarySearch(sizes.cumulative, weight)
+		return sizes.sizes[min(idx, len(sizes.sizes)-1)]
+	default:
+		return sizes.size
+	}
+}
+
+// next returns the next body, it's shared read only content which must not be modified.
+func (generator *BodyGenerator) next() []byte {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+	size := generator.sizes.next(generator.rand)
+	var offset int
+	if generator.offset {
+		offset = generator.rand.Intn(len(generator.source) - size + 1)
+	}
+	return generator.source[offset : offset+size]
+}
+
+// Next returns the next body for scripts which need it in JS.
+func (generator *BodyGenerator) Next() []byte {
+	return slices.Clone(generator.next())
+}
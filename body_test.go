@@ -0,0 +1,130 @@
+package k9amqp
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeHistogram(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sizes.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadHistogram(t *testing.T) {
+	path := writeHistogram(t, "# size weight\n100 1\n\n1000,3\n 10000\t0.5 \n")
+	sizes, cumulative, err := readHistogram(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{100, 1000, 10000}; !slices.Equal(sizes, want) {
+		t.Errorf("sizes %v, want %v", sizes, want)
+	}
+	if want := []float64{1, 4, 4.5}; !slices.Equal(cumulative, want) {
+		t.Errorf("cumulative weights %v, want %v", cumulative, want)
+	}
+}
+
+func TestReadHistogramInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"fields":   "100 1 2\n",
+		"size":     "-1 1\n",
+		"weight":   "100 heavy\n",
+		"unweight": "100 0\n",
+		"empty":    "# no sizes\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := readHistogram(writeHistogram(t, content)); err == nil {
+				t.Errorf("histogram %q accepted", content)
+			}
+		})
+	}
+	if _, _, err := readHistogram(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("missing histogram file accepted")
+	}
+}
+
+func TestSizeDistribution(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     BodyOptions
+		min, max int
+	}{
+		{"fixed", BodyOptions{Size: 512}, 512, 512},
+		{"uniform", BodyOptions{Distribution: SizeUniform, Min: 10, Max: 20}, 10, 20},
+		{"normal", BodyOptions{Distribution: SizeNormal, Mean: 100, StdDev: 50}, 0, 300},
+		{"normal bounded", BodyOptions{Distribution: SizeNormal, Mean: 100, StdDev: 50, Min: 80, Max: 120}, 80, 120},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sizes, err := newSizeDistribution(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := rand.New(rand.NewSource(1))
+			for range 1000 {
+				if size := sizes.next(r); size < test.min || size > test.max {
+					t.Fatalf("size %d out of %d-%d", size, test.min, test.max)
+				}
+			}
+		})
+	}
+}
+
+func TestSizeDistributionHistogram(t *testing.T) {
+	sizes, err := newSizeDistribution(BodyOptions{Distribution: SizeHistogram, Histogram: writeHistogram(t, "100 1\n200 0\n300 1\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sizes.max != 300 {
+		t.Errorf("max size %d, want 300", sizes.max)
+	}
+	counts := make(map[int]int)
+	r := rand.New(rand.NewSource(1))
+	for range 1000 {
+		counts[sizes.next(r)]++
+	}
+	if counts[200] != 0 || counts[100] < 400 || counts[300] < 400 {
+		t.Errorf("size counts %v, want sizes 100 and 300 evenly", counts)
+	}
+}
+
+func TestSizeDistributionInvalid(t *testing.T) {
+	for name, opts := range map[string]BodyOptions{
+		"negative": {Size: -1},
+		"uniform":  {Distribution: SizeUniform, Min: 20, Max: 10},
+		"normal":   {Distribution: SizeNormal, StdDev: 10},
+		"bounds":   {Distribution: SizeNormal, Mean: 100, Min: 50, Max: 10},
+		"unknown":  {Distribution: "pareto"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newSizeDistribution(opts); err == nil {
+				t.Errorf("options %+v accepted", opts)
+			}
+		})
+	}
+}
+
+func TestBodyGenerator(t *testing.T) {
+	generator, err := newBodyGenerator(BodyOptions{Kind: BodyKindASCII, Distribution: SizeUniform, Min: 1, Max: 64, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 100 {
+		body := generator.next()
+		if len(body) < 1 || len(body) > 64 {
+			t.Fatalf("body size %d out of 1-64", len(body))
+		}
+		for _, b := range body {
+			if !slices.Contains([]byte(charset), b) {
+				t.Fatalf("body byte %q is not ascii letter", b)
+			}
+		}
+	}
+}
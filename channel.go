@@ -0,0 +1,131 @@
+package k9amqp
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errChannelClosed = errors.New("amqp channel handle closed")
+
+// Channel is a pooled channel pinned by a script for channel scoped operations. It's returned to the pool
+// by close or when the VU ends the iteration.
+type Channel struct {
+	client    *Client
+	channel   *amqpChannel
+	iteration int64
+	closed    bool
+	qos       bool
+	discard   bool
+}
+
+// Channel pins a pooled channel until it's closed.
+func (client *Client) Channel() (*Channel, error) {
+	return client.pin(false)
+}
+
+// pin acquires channel for a handle, discarded channel is closed instead of being returned to the pool.
+func (client *Client) pin(discard bool) (*Channel, error) {
+	channel, err := client.acquire()
+	if err != nil {
+		slog.Error("unable to get amqp channel")
+		return nil, err
+	}
+	handle := &Channel{client: client, channel: channel, iteration: client.iteration(), discard: discard}
+	client.handles = append(client.handles, handle)
+	return handle, nil
+}
+
+func (client *Client) iteration() int64 {
+	if state := client.k9amqp.vu.State(); state != nil {
+		return state.Iteration
+	}
+	return -1
+}
+
+// releaseHandles returns channels pinned in previous iterations to the pool, it's a fallback for k6
+// not emitting iteration events.
+func (client *Client) releaseHandles() {
+	iteration := client.iteration()
+	for _, handle := range slices.Clone(client.handles) {
+		if handle.iteration != iteration {
+			slog.Debug("releasing channel pinned in previous iteration", "iteration", handle.iteration)
+			if err := handle.Close(); err != nil {
+				slog.Error("failed to release pinned channel", "error", err)
+			}
+		}
+	}
+}
+
+// closeHandles returns all pinned channels to the pool.
+func (client *Client) closeHandles() {
+	for _, handle := range slices.Clone(client.handles) {
+		slog.Debug("releasing channel pinned in ended iteration", "iteration", handle.iteration)
+		if err := handle.Close(); err != nil {
+			slog.Error("failed to release pinned channel", "error", err)
+		}
+	}
+}
+
+// Close returns the channel to the pool, channel with changed QoS or in transactional mode is closed.
+func (c *Channel) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.client.handles = slices.DeleteFunc(c.client.handles, func(handle *Channel) bool {
+		return handle == c
+	})
+	if c.channel.IsClosed() || (!c.discard && !c.qos) {
+		return c.channel.pool.put(c.channel, nil)
+	}
+	return c.channel.Close()
+}
+
+func (c *Channel) Publish(opts PublishOptions, msg amqp.Publishing) (AmqpProduceResponse, error) {
+	if c.closed {
+		return AmqpProduceResponse{Error: true, ErrorMessage: errChannelClosed.Error()}, errChannelClosed
+	}
+	if err := opts.init(); err != nil {
+		return AmqpProduceResponse{Error: true, ErrorMessage: err.Error()}, err
+	}
+	return c.client.publishOn(c.channel, opts, msg)
+}
+
+func (c *Channel) Get(opts GetOptions) (AmqpGetResponse, error) {
+	if c.closed {
+		return AmqpGetResponse{Error: true, ErrorMessage: errChannelClosed.Error()}, errChannelClosed
+	}
+	return c.client.getOn(c.channel, opts)
+}
+
+func (c *Channel) Consume(opts ConsumeOptions) (AmqpConsumeResponse, error) {
+	if c.closed {
+		return AmqpConsumeResponse{Error: true, ErrorMessage: errChannelClosed.Error()}, errChannelClosed
+	}
+	return c.client.consumeOn(c.channel, opts)
+}
+
+func (c *Channel) Ack(deliveryTag uint64, multiple bool) error {
+	if c.closed {
+		return errChannelClosed
+	}
+	return c.channel.Ack(deliveryTag, multiple)
+}
+
+func (c *Channel) Nack(deliveryTag uint64, multiple, requeue bool) error {
+	if c.closed {
+		return errChannelClosed
+	}
+	return c.channel.Nack(deliveryTag, multiple, requeue)
+}
+
+func (c *Channel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	if c.closed {
+		return errChannelClosed
+	}
+	c.qos = true
+	return c.channel.Qos(prefetchCount, prefetchSize, global)
+}
@@ -0,0 +1,38 @@
+package k9amqp
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestHandlesReleasedAtIterationEnd(t *testing.T) {
+	pool := benchmarkPool(t, PoolOptions{ChannelsCacheSize: 2, MaxChannels: 2, AcquireTimeout: "10ms"})
+	client := &Client{amqpClient: &AmqpClient{poolOptions: PoolOptions{Mode: PoolModeShared}, channels: pool}}
+	ctx := context.Background()
+	for range 2 {
+		channel, err := pool.get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.handles = append(client.handles, &Channel{client: client, channel: channel})
+	}
+	if _, err := pool.get(ctx); err == nil {
+		t.Fatal("pool with all channels pinned returned a channel")
+	}
+	handles := slices.Clone(client.handles)
+	client.iterationEnd()
+	if len(client.handles) != 0 {
+		t.Errorf("%d handles left after iteration end", len(client.handles))
+	}
+	for idx, handle := range handles {
+		if !handle.closed {
+			t.Errorf("handle %d not closed", idx)
+		}
+	}
+	for range 2 {
+		if _, err := pool.get(ctx); err != nil {
+			t.Errorf("released channel not acquired: %v", err)
+		}
+	}
+}
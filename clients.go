@@ -0,0 +1,130 @@
+package k9amqp
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// amqpClients holds clients shared by all VUs, keyed by client name or by options of unnamed clients.
+var (
+	amqpClients      = make(map[string]*AmqpClient)
+	defaultClient    *AmqpClient
+	amqpClientsMutex sync.Mutex
+)
+
+// optionsFingerprint identifies initialized client options, so options naming the same broker with
+// defaults spelled out or left out are equal. Client name is not part of the fingerprint.
+func optionsFingerprint(amqpOptions AmqpOptions, poolOptions PoolOptions) (string, error) {
+	endpoints := make([]string, len(amqpOptions.endpoints))
+	for idx, endpoint := range amqpOptions.endpoints {
+		endpoints[idx] = fmt.Sprintf("%s frame_max=%d weight=%d", endpoint.uri.String(), endpoint.frameMax, endpoint.weight)
+	}
+	amqpOptions.Name, amqpOptions.URI, amqpOptions.URIs, amqpOptions.Nodes = "", "", nil, nil
+	amqpOptions.Host, amqpOptions.Port, amqpOptions.Vhost = "", 0, ""
+	amqpOptions.Username, amqpOptions.Password = "", ""
+	amqpOptions.Heartbeat, amqpOptions.DialTimeout = amqpOptions.heartbeat.String(), amqpOptions.dialTimeout.String()
+	poolOptions.AcquireTimeout = poolOptions.acquireTimeout.String()
+	poolOptions.MaxChannelAge = poolOptions.maxChannelAge.String()
+	poolOptions.IdleTimeout = poolOptions.idleTimeout.String()
+	poolOptions.RotateInterval = poolOptions.rotateInterval.String()
+	poolOptions.Recovery.InitialInterval = poolOptions.Recovery.initialInterval.String()
+	poolOptions.Recovery.MaxInterval = poolOptions.Recovery.maxInterval.String()
+	fingerprint, err := json.Marshal(struct {
+		Endpoints []string
+		Amqp      AmqpOptions
+		Pool      PoolOptions
+	}{endpoints, amqpOptions, poolOptions})
+	if err != nil {
+		return "", fmt.Errorf("unable to compare client options: %w", err)
+	}
+	return string(fingerprint), nil
+}
+
+// noOptions reports whether the client is created with no options apart from its name.
+func noOptions(amqpOptions AmqpOptions, poolOptions PoolOptions) (bool, error) {
+	amqpOptions.Name = ""
+	options, err := json.Marshal([]any{amqpOptions, poolOptions})
+	if err != nil {
+		return false, fmt.Errorf("unable to compare client options: %w", err)
+	}
+	empty, err := json.Marshal([]any{AmqpOptions{}, PoolOptions{}})
+	if err != nil {
+		return false, fmt.Errorf("unable to compare client options: %w", err)
+	}
+	return string(options) == string(empty), nil
+}
+
+// sharedAmqpClient returns already initialized client or initializes a new one.
+// Client created with no options is the first client created. The client is registered before its
+// connections are opened, so opening them doesn't block creating other clients.
+func sharedAmqpClient(amqpOptions AmqpOptions, poolOptions PoolOptions, scope string) (*AmqpClient, error) {
+	empty, err := noOptions(amqpOptions, poolOptions)
+	if err != nil {
+		return nil, err
+	}
+	amqpClient := &AmqpClient{scope: scope, amqpOptions: amqpOptions, poolOptions: poolOptions}
+	if err := amqpClient.init(); err != nil {
+		return nil, err
+	}
+	fingerprint, err := optionsFingerprint(amqpClient.amqpOptions, amqpClient.poolOptions)
+	if err != nil {
+		return nil, err
+	}
+	amqpClientsMutex.Lock()
+	name := amqpOptions.Name
+	if name == "" && empty && defaultClient != nil {
+		existing := defaultClient
+		amqpClientsMutex.Unlock()
+		return existing.wait()
+	}
+	key := "options:" + fingerprint
+	if name != "" {
+		key = "name:" + name
+	}
+	if existing, ok := amqpClients[key]; ok {
+		amqpClientsMutex.Unlock()
+		if name != "" && !empty && fingerprint != existing.fingerprint {
+			return nil, fmt.Errorf("amqp client '%s' already exists with different options", name)
+		}
+		return existing.wait()
+	}
+	amqpClient.key, amqpClient.fingerprint = key, fingerprint
+	amqpClient.ready = make(chan struct{})
+	amqpClients[key] = amqpClient
+	if defaultClient == nil {
+		defaultClient = amqpClient
+	}
+	amqpClientsMutex.Unlock()
+
+	slog.Info(fmt.Sprintf("init amqp client '%s' with pool %+v\n", name, poolOptions))
+	amqpClient.openErr = amqpClient.open()
+	close(amqpClient.ready)
+	if amqpClient.openErr != nil {
+		releaseAmqpClient(amqpClient)
+		return nil, amqpClient.openErr
+	}
+	return amqpClient, nil
+}
+
+// wait returns the registered client once its connections are opened.
+func (amqpClient *AmqpClient) wait() (*AmqpClient, error) {
+	<-amqpClient.ready
+	if amqpClient.openErr != nil {
+		return nil, amqpClient.openErr
+	}
+	return amqpClient, nil
+}
+
+// releaseAmqpClient forgets closed client, so the same name is able to init a new one.
+func releaseAmqpClient(amqpClient *AmqpClient) {
+	amqpClientsMutex.Lock()
+	defer amqpClientsMutex.Unlock()
+	if amqpClients[amqpClient.key] == amqpClient {
+		delete(amqpClients, amqpClient.key)
+	}
+	if defaultClient == amqpClient {
+		defaultClient = nil
+	}
+}
@@ -0,0 +1,84 @@
+package k9amqp
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// connSeq numbers connections opened by the process, the number is part of default connection name.
+var connSeq atomic.Int64
+
+func (opt *AmqpOptions) initTuning() error {
+	var err error
+	if opt.Heartbeat != "" {
+		if opt.heartbeat, err = time.ParseDuration(opt.Heartbeat); err != nil {
+			return fmt.Errorf("invalid heartbeat: %w", err)
+		}
+	}
+	if opt.DialTimeout != "" {
+		if opt.dialTimeout, err = time.ParseDuration(opt.DialTimeout); err != nil {
+			return fmt.Errorf("invalid dial_timeout: %w", err)
+		}
+	}
+	if opt.ChannelMax < 0 || opt.ChannelMax > math.MaxUint16 {
+		return fmt.Errorf("channel_max must be between 0 and %d", math.MaxUint16)
+	}
+	if opt.FrameMax < 0 {
+		return fmt.Errorf("frame_max must not be negative")
+	}
+	if opt.Auth.Mechanism != "" {
+		if _, err := authMechanism(opt.Auth.Mechanism); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// config builds connection configuration, tuning given by URI query parameters takes precedence over options.
+func (amqpClient *AmqpClient) config(endpoint amqpEndpoint, scope string) (amqp.Config, error) {
+	opt := amqpClient.amqpOptions
+	sasl, err := opt.Auth.sasl(endpoint.uri)
+	if err != nil {
+		return amqp.Config{}, err
+	}
+	properties := amqp.NewConnectionProperties()
+	for key, value := range opt.ClientProperties {
+		properties[key] = value
+	}
+	properties.SetClientConnectionName(amqpClient.connectionName(scope))
+	amqpConfig := amqp.Config{
+		SASL:       sasl,
+		Vhost:      endpoint.uri.Vhost,
+		FrameSize:  opt.FrameMax,
+		Heartbeat:  opt.heartbeat,
+		Locale:     opt.Locale,
+		Properties: properties,
+	}
+	if endpoint.frameMax != 0 {
+		amqpConfig.FrameSize = endpoint.frameMax
+	}
+	if endpoint.uri.ChannelMax == 0 {
+		amqpConfig.ChannelMax = uint16(opt.ChannelMax) //nolint:gosec // checked by initTuning
+	}
+	if opt.dialTimeout > 0 && endpoint.uri.ConnectionTimeout == 0 {
+		amqpConfig.Dial = amqp.DefaultDial(opt.dialTimeout)
+	}
+	if amqpClient.tlsConfig != nil {
+		// amqp.DialConfig sets ServerName of the config to the dialed host, clone keeps nodes apart
+		amqpConfig.TLSClientConfig = amqpClient.tlsConfig.Clone()
+	}
+	return amqpConfig, nil
+}
+
+// connectionName is shown in RabbitMQ management UI, defaults to k6-<scope>-<n>.
+func (amqpClient *AmqpClient) connectionName(scope string) string {
+	seq := connSeq.Add(1)
+	if amqpClient.amqpOptions.ConnectionName != "" {
+		return fmt.Sprintf("%s-%d", amqpClient.amqpOptions.ConnectionName, seq)
+	}
+	return fmt.Sprintf("k6-%s-%d", scope, seq)
+}
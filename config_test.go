@@ -0,0 +1,103 @@
+package k9amqp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       AmqpOptions
+		heartbeat  time.Duration
+		frameSize  int
+		channelMax uint16
+	}{
+		{"defaults", AmqpOptions{URI: "amqp://rabbitmq"}, 0, 0, 0},
+		{"options", AmqpOptions{URI: "amqp://rabbitmq", Heartbeat: "5s", FrameMax: 4096, ChannelMax: 100}, 5 * time.Second, 4096, 100},
+		{"uri frame_max", AmqpOptions{URI: "amqp://rabbitmq?frame_max=8192", FrameMax: 4096}, 0, 8192, 0},
+		{"uri channel_max", AmqpOptions{URI: "amqp://rabbitmq?channel_max=10", ChannelMax: 100}, 0, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amqpClient := &AmqpClient{amqpOptions: test.opts}
+			if err := amqpClient.init(); err != nil {
+				t.Fatal(err)
+			}
+			config, err := amqpClient.config(amqpClient.amqpOptions.endpoints[0], "orders")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Heartbeat != test.heartbeat || config.FrameSize != test.frameSize || config.ChannelMax != test.channelMax {
+				t.Errorf("heartbeat %s, frame size %d, channel max %d, want %s, %d, %d",
+					config.Heartbeat, config.FrameSize, config.ChannelMax, test.heartbeat, test.frameSize, test.channelMax)
+			}
+			if config.Vhost != "/" || config.TLSClientConfig != nil || config.SASL != nil {
+				t.Errorf("vhost %q, tls %v, sasl %v", config.Vhost, config.TLSClientConfig, config.SASL)
+			}
+		})
+	}
+}
+
+func TestConfigTLSCloned(t *testing.T) {
+	amqpClient := &AmqpClient{amqpOptions: AmqpOptions{URIs: []string{"amqps://rabbitmq-1", "amqps://rabbitmq-2"}, TLS: TLSOptions{Enabled: true}}}
+	if err := amqpClient.init(); err != nil {
+		t.Fatal(err)
+	}
+	first, err := amqpClient.config(amqpClient.amqpOptions.endpoints[0], "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := amqpClient.config(amqpClient.amqpOptions.endpoints[1], "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.TLSClientConfig == nil || first.TLSClientConfig == amqpClient.tlsConfig || first.TLSClientConfig == second.TLSClientConfig {
+		t.Error("tls config of the client shared by connections")
+	}
+	first.TLSClientConfig.ServerName = "rabbitmq-1"
+	if amqpClient.tlsConfig.ServerName != "" || second.TLSClientConfig.ServerName != "" {
+		t.Error("server name of a node leaked to other connections")
+	}
+}
+
+func TestConnectionName(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   AmqpOptions
+		scope  string
+		prefix string
+	}{
+		{"default", AmqpOptions{}, "orders", "k6-orders-"},
+		{"shared", AmqpOptions{}, "shared", "k6-shared-"},
+		{"option", AmqpOptions{ConnectionName: "load"}, "orders", "load-"},
+	}
+	for _, test := range tests {
+		amqpClient := &AmqpClient{amqpOptions: test.opts}
+		first, second := amqpClient.connectionName(test.scope), amqpClient.connectionName(test.scope)
+		var firstSeq, secondSeq int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(first, test.prefix), "%d", &firstSeq); err != nil || !strings.HasPrefix(first, test.prefix) {
+			t.Errorf("%s: connection named %q, want %s<n>", test.name, first, test.prefix)
+		}
+		if _, err := fmt.Sscanf(strings.TrimPrefix(second, test.prefix), "%d", &secondSeq); err != nil || secondSeq <= firstSeq {
+			t.Errorf("%s: connections named %q, %q, want increasing numbers", test.name, first, second)
+		}
+	}
+}
+
+func TestConfigConnectionName(t *testing.T) {
+	amqpClient := &AmqpClient{amqpOptions: AmqpOptions{ConnectionName: "load", ClientProperties: map[string]any{"team": "orders"}}}
+	if err := amqpClient.init(); err != nil {
+		t.Fatal(err)
+	}
+	config, err := amqpClient.config(amqpClient.amqpOptions.endpoints[0], "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, _ := config.Properties["connection_name"].(string)
+	if !strings.HasPrefix(name, "load-") || config.Properties["team"] != "orders" {
+		t.Errorf("client properties %v", config.Properties)
+	}
+}
@@ -0,0 +1,89 @@
+package k9amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	errConfirmTimeout    = errors.New("amqp publish confirm timed out")
+	errConfirmWindowFull = errors.New("amqp confirm window full")
+)
+
+// confirmMode puts the channel into confirm mode, pooled channels stay in it once selected.
+func (channel *amqpChannel) confirmMode() error {
+	if channel.confirming {
+		return nil
+	}
+	if err := channel.Confirm(false); err != nil {
+		return err
+	}
+	channel.confirming = true
+	return nil
+}
+
+// waitConfirm waits for broker ack or nack of the published message, until publish timeout when set.
+func (client *Client) waitConfirm(confirmation *amqp.DeferredConfirmation, opts PublishOptions) (bool, error) {
+	ctx := client.context()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false, fmt.Errorf("%w after %s", errConfirmTimeout, opts.Timeout)
+	}
+	return acked, err
+}
+
+// confirmTracker bounds publishes of a channel waiting for confirm in async confirm mode.
+type confirmTracker struct {
+	window chan struct{}
+}
+
+func (channel *amqpChannel) tracker(window int) *confirmTracker {
+	if channel.confirms == nil {
+		channel.confirms = &confirmTracker{window: make(chan struct{}, window)}
+	}
+	return channel.confirms
+}
+
+// reserve takes a place in the window, it waits for a confirm to arrive when the window is full.
+func (tracker *confirmTracker) reserve(ctx context.Context, timeout time.Duration) error {
+	select {
+	case tracker.window <- struct{}{}:
+		return nil
+	default:
+	}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case tracker.window <- struct{}{}:
+		return nil
+	case <-expired:
+		return fmt.Errorf("%w, %d publishes not confirmed within %s", errConfirmWindowFull, cap(tracker.window), timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (tracker *confirmTracker) release() {
+	<-tracker.window
+}
+
+// await reports the confirm once it arrives and frees its place in the window. Publishes
+// pending when the channel is closed are reported as nacked.
+func (tracker *confirmTracker) await(confirmation *amqp.DeferredConfirmation, startTime time.Time, report func(time.Duration, bool)) {
+	<-confirmation.Done()
+	tracker.release()
+	report(time.Since(startTime), confirmation.Acked())
+}
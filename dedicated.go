@@ -0,0 +1,91 @@
+package k9amqp
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	PoolModeShared       = "shared"
+	PoolModePerVU        = "per_vu"
+	PoolModePerIteration = "per_iteration"
+)
+
+// vuConnection is a connection owned by single VU in per_vu and per_iteration pool modes.
+type vuConnection struct {
+	mutex     sync.Mutex
+	conn      *amqpConnection
+	channels  *AmqpPool
+	iteration int64
+	watched   bool
+}
+
+func validPoolMode(mode string) error {
+	switch mode {
+	case PoolModeShared, PoolModePerVU, PoolModePerIteration:
+		return nil
+	default:
+		return fmt.Errorf("unsupported pool mode '%s'", mode)
+	}
+}
+
+// dedicated reports whether VUs use their own connections instead of the shared pool.
+func (amqpClient *AmqpClient) dedicated() bool {
+	return amqpClient.poolOptions.Mode != PoolModeShared
+}
+
+// dedicatedPool returns channels of the VU's own connection. The connection is opened lazily and
+// reopened when it's closed. In per_iteration mode it's closed when the iteration ends, the check
+// of iteration is a fallback for k6 not emitting iteration events.
+func (client *Client) dedicatedPool() (*AmqpPool, error) {
+	client.watchIterations()
+	vuConn := &client.vuConn
+	vuConn.mutex.Lock()
+	defer vuConn.mutex.Unlock()
+	var vuID uint64
+	var iteration int64 = -1
+	state := client.k9amqp.vu.State()
+	if state != nil {
+		vuID, iteration = state.VUID, state.Iteration
+	}
+	if vuConn.conn != nil {
+		perIteration := client.amqpClient.poolOptions.Mode == PoolModePerIteration
+		if !vuConn.conn.IsClosed() && (!perIteration || vuConn.iteration == iteration) {
+			return vuConn.channels, nil
+		}
+		vuConn.close()
+	}
+	startTime := time.Now()
+	conn, err := client.amqpClient.connectNode(client.amqpClient.selector.candidates(int(vuID)), make(map[int]bool), client.connScope())
+	if err != nil {
+		return nil, err
+	}
+	client.k9amqp.reportConnectMetrics(conn.endpoint, time.Since(startTime))
+	slog.Debug("vu connection opened", "vu", vuID, "iteration", iteration, "endpoint", conn.endpoint.String())
+	vuConn.conn, vuConn.iteration = conn, iteration
+	vuConn.channels = newAmqpPool([]*amqpConnection{conn}, PoolOptions{ChannelsCacheSize: 1}, client.amqpClient.amqpOptions.endpoints)
+	vuConn.channels.returned = client.amqpClient.returned
+	if state != nil && !vuConn.watched {
+		vuConn.watched = true
+		go func(done <-chan struct{}) {
+			<-done
+			vuConn.mutex.Lock()
+			defer vuConn.mutex.Unlock()
+			vuConn.close()
+		}(client.k9amqp.vu.Context().Done())
+	}
+	return vuConn.channels, nil
+}
+
+// close closes the VU connection together with its channels, the caller holds the mutex.
+func (vuConn *vuConnection) close() {
+	if vuConn.conn == nil {
+		return
+	}
+	if err := vuConn.conn.Close(); err != nil {
+		slog.Debug("failed to close vu connection", "error", err)
+	}
+	vuConn.conn, vuConn.channels = nil, nil
+}
@@ -0,0 +1,27 @@
+#!/usr/bin/env bash
+# Generates self-signed CA, RabbitMQ server and k6 client certificates into ./certs
+
+set -euo pipefail
+
+CERTS_DIR="$(dirname "$0")/certs"
+SERVER_CN="${SERVER_CN:-rabbitmq}"
+
+mkdir -p "${CERTS_DIR}"
+cd "${CERTS_DIR}"
+
+openssl req -x509 -newkey rsa:2048 -nodes -days 365 \
+  -subj "/CN=k9amqp-test-ca" -keyout ca_key.pem -out ca_certificate.pem
+
+openssl req -newkey rsa:2048 -nodes -subj "/CN=${SERVER_CN}" \
+  -keyout server_key.pem -out server_req.pem
+openssl x509 -req -days 365 -in server_req.pem -CA ca_certificate.pem -CAkey ca_key.pem -CAcreateserial \
+  -extfile <(printf "subjectAltName=DNS:%s,DNS:localhost,IP:127.0.0.1" "${SERVER_CN}") \
+  -out server_certificate.pem
+
+openssl req -newkey rsa:2048 -nodes -subj "/CN=guest" \
+  -keyout client_key.pem -out client_req.pem
+openssl x509 -req -days 365 -in client_req.pem -CA ca_certificate.pem -CAkey ca_key.pem -CAcreateserial \
+  -out client_certificate.pem
+
+rm -f server_req.pem client_req.pem
+chmod 0644 ./*.pem
@@ -0,0 +1,76 @@
+package k9amqp
+
+import (
+	"maps"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.k6.io/k6/v2/metrics"
+)
+
+// sentHeader holds publish time in Unix nanoseconds for end-to-end latency.
+const sentHeader = "x-k9amqp-sent-ns"
+
+// stampSent returns copy of the headers with publish time.
+func stampSent(headers amqp.Table, now time.Time) amqp.Table {
+	stamped := make(amqp.Table, len(headers)+1)
+	maps.Copy(stamped, headers)
+	stamped[sentHeader] = now.UnixNano()
+	return stamped
+}
+
+// sentLatency returns time since the delivery was published, when it was stamped by publish.
+func sentLatency(delivery *amqp.Delivery, now time.Time) (time.Duration, bool) {
+	var sent int64
+	switch value := delivery.Headers[sentHeader].(type) {
+	case int64:
+		sent = value
+	case int32:
+		sent = int64(value)
+	case float64:
+		sent = int64(value)
+	default:
+		return 0, false
+	}
+	return now.Sub(time.Unix(0, sent)), true
+}
+
+// e2eSamples returns end-to-end latency of stamped deliveries tagged by exchange, routing key and queue.
+func (k9amqp *K9amqp) e2eSamples(now time.Time, tags *metrics.TagSet, metadata map[string]string, queue string, deliveries []amqp.Delivery) []metrics.Sample {
+	var samples []metrics.Sample
+	for idx := range deliveries {
+		latency, ok := sentLatency(&deliveries[idx], now)
+		if !ok {
+			continue
+		}
+		deliveryTags := tags.With("exchange", deliveries[idx].Exchange)
+		deliveryTags = deliveryTags.With("routing_key", deliveries[idx].RoutingKey)
+		deliveryTags = deliveryTags.With("queue", queue)
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.E2ELatency,
+				Tags:   deliveryTags,
+			},
+			Value:    metrics.D(latency),
+			Metadata: metadata,
+		})
+	}
+	return samples
+}
+
+// e2eReporter captures VU metrics context of listen, so deliveries received in background are reported.
+func (k9amqp *K9amqp) e2eReporter(queue string) func(amqp.Delivery) {
+	state := k9amqp.vu.State()
+	if state == nil {
+		return func(amqp.Delivery) {}
+	}
+	ctx := k9amqp.vu.Context()
+	ctm := state.Tags.GetCurrentValues()
+	return func(delivery amqp.Delivery) {
+		samples := k9amqp.e2eSamples(time.Now(), ctm.Tags, ctm.Metadata, queue, []amqp.Delivery{delivery})
+		if len(samples) > 0 {
+			metrics.PushIfNotDone(ctx, state.Samples, metrics.ConnectedSamples{Samples: samples})
+		}
+	}
+}
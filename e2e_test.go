@@ -0,0 +1,48 @@
+package k9amqp
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestStampSent(t *testing.T) {
+	now := time.Unix(1700000000, 123)
+	headers := amqp.Table{"tenant": "a"}
+	stamped := stampSent(headers, now)
+	if stamped[sentHeader] != now.UnixNano() || stamped["tenant"] != "a" {
+		t.Errorf("stamped headers %v", stamped)
+	}
+	if _, ok := headers[sentHeader]; ok || len(headers) != 1 {
+		t.Error("headers of the message modified")
+	}
+	if stamped = stampSent(nil, now); len(stamped) != 1 {
+		t.Errorf("stamped nil headers %v", stamped)
+	}
+}
+
+func TestSentLatency(t *testing.T) {
+	sent := time.Unix(1700000000, 0)
+	now := sent.Add(250 * time.Millisecond)
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    time.Duration
+		ok      bool
+	}{
+		{"int64", amqp.Table{sentHeader: sent.UnixNano()}, 250 * time.Millisecond, true},
+		{"float64", amqp.Table{sentHeader: float64(sent.UnixNano())}, 250 * time.Millisecond, true},
+		{"int32", amqp.Table{sentHeader: int32(0)}, now.Sub(time.Unix(0, 0)), true},
+		{"missing", amqp.Table{"tenant": "a"}, 0, false},
+		{"no headers", nil, 0, false},
+		{"string", amqp.Table{sentHeader: "1700000000000000000"}, 0, false},
+		{"bytes", amqp.Table{sentHeader: []byte{1, 2}}, 0, false},
+	}
+	for _, test := range tests {
+		got, ok := sentLatency(&amqp.Delivery{Headers: test.headers}, now)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: latency %s, %t, want %s, %t", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
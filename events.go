@@ -0,0 +1,58 @@
+package k9amqp
+
+import (
+	"log/slog"
+	"math"
+)
+
+// iterEndEvent is k6 event emitted when a VU ends an iteration, k6 event types are internal,
+// so the type is looked up by its name.
+const iterEndEvent = "IterEnd"
+
+func eventType[T interface {
+	~uint8
+	String() string
+}, C any](_ func(...T) (uint64, C), name string) (T, bool) {
+	for value := range math.MaxUint8 {
+		if T(value).String() == name {
+			return T(value), true
+		}
+	}
+	return 0, false
+}
+
+// watchIterations runs iterationEnd once the VU ends an iteration, the VU waits for it before it
+// continues. It's subscribed once by the first call of the client made by a VU.
+func (client *Client) watchIterations() {
+	if client.k9amqp.vu.State() == nil {
+		return
+	}
+	client.iterations.Do(func() {
+		local := client.k9amqp.vu.Events().Local
+		if local == nil {
+			return
+		}
+		iterEnd, ok := eventType(local.Subscribe, iterEndEvent)
+		if !ok {
+			slog.Warn("k6 iteration end event not found, iteration scoped resources are released by next iteration")
+			return
+		}
+		_, events := local.Subscribe(iterEnd)
+		go func() {
+			for event := range events {
+				client.iterationEnd()
+				event.Done()
+			}
+		}()
+	})
+}
+
+// iterationEnd releases resources scoped to the VU's iteration.
+func (client *Client) iterationEnd() {
+	client.closeHandles()
+	if client.amqpClient.poolOptions.Mode == PoolModePerIteration {
+		client.vuConn.mutex.Lock()
+		client.vuConn.close()
+		client.vuConn.mutex.Unlock()
+	}
+}
@@ -0,0 +1,29 @@
+package k9amqp
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testEvent uint8
+
+func (event testEvent) String() string {
+	names := []string{"Init", "IterStart", "IterEnd"}
+	if int(event) >= len(names) {
+		return fmt.Sprintf("testEvent(%d)", event)
+	}
+	return names[event]
+}
+
+func testSubscribe(...testEvent) (uint64, <-chan struct{}) {
+	return 0, nil
+}
+
+func TestEventType(t *testing.T) {
+	if got, ok := eventType(testSubscribe, iterEndEvent); !ok || got != 2 {
+		t.Errorf("eventType(IterEnd) = %d, %t, want 2", got, ok)
+	}
+	if _, ok := eventType(testSubscribe, "Exit"); ok {
+		t.Error("unknown event type found")
+	}
+}
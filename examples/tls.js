@@ -0,0 +1,48 @@
+import k9amqp from 'k6/x/k9amqp';
+import queue from 'k6/x/k9amqp/queue';
+
+export const options = {
+  vus: 5,
+  duration: '30s',
+}
+
+const amqpOptions = {
+  host : __ENV.AMQP_HOST || "localhost",
+  port : __ENV.AMQP_PORT || 5671,
+  vhost : __ENV.AMQP_VHOST || "/",
+  username : __ENV.AMQP_USERNAME || "guest",
+  password : __ENV.AMQP_PASSWORD || "guest",
+  tls : {
+    enabled : true,
+    ca_file : __ENV.AMQP_TLS_CA_FILE || "docker/rabbitmq-tls/certs/ca_certificate.pem",
+    cert_file : __ENV.AMQP_TLS_CERT_FILE || "docker/rabbitmq-tls/certs/client_certificate.pem",
+    key_file : __ENV.AMQP_TLS_KEY_FILE || "docker/rabbitmq-tls/certs/client_key.pem",
+    server_name : __ENV.AMQP_TLS_SERVER_NAME || "rabbitmq",
+    min_version : "1.2",
+  }
+}
+
+const poolOptions = {
+  channels_per_conn : __ENV.AMQP_CHANNELS_PER_CONN || 2,
+  channels_cache_size : __ENV.AMQP_CACHE_SIZE || 10,
+}
+
+// Inits K9 AMQP Client connected over amqps://
+const client = new k9amqp.Client(amqpOptions, poolOptions)
+
+export function setup() {
+  const client = new k9amqp.Client(amqpOptions, poolOptions)
+  queue.declare(client, {name: "test.tls.q", durable: false, auto_delete: false})
+}
+
+export function teardown(data) {
+  const client = new k9amqp.Client()
+  queue.delete(client, {name: "test.tls.q"})
+  client.teardown()
+}
+
+export default function() {
+  // Publishes to the default exchange, routing key is the queue name
+  client.publish({ exchange: "", key: "test.tls.q"}, { content_type: "text/plain", body: "hello over TLS" })
+  client.get({queue: "test.tls.q", auto_ack: true})
+}
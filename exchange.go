@@ -10,19 +10,19 @@ func (queue *Exchange) Declare(client *Client, opts ExchangeDeclareOptions) erro
 	if client == nil {
 		return errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
 				slog.Error("failed to close channel after error", "error", closeErr)
 			}
 		}
@@ -39,6 +39,7 @@ func (queue *Exchange) Declare(client *Client, opts ExchangeDeclareOptions) erro
 	if err != nil {
 		return err
 	}
+	client.amqpClient.topology.declareExchange(opts)
 	slog.Info("exchange created", "name", opts.Name)
 	return nil
 }
@@ -48,19 +49,19 @@ func (queue *Exchange) Delete(client *Client, opts ExchangeDeleteOptions) error
 	if client == nil {
 		return errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
 				slog.Error("failed to close channel after error", "error", closeErr)
 			}
 		}
@@ -73,6 +74,7 @@ func (queue *Exchange) Delete(client *Client, opts ExchangeDeleteOptions) error
 	if err != nil {
 		return err
 	}
+	client.amqpClient.topology.deleteExchange(opts.Name)
 	slog.Info("exchange deleted", "name", opts.Name)
 	return nil
 }
@@ -81,19 +83,19 @@ func (exchange *Exchange) Bind(client *Client, opts ExchangeBindOptions) error {
 	if client == nil {
 		return errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
 				slog.Error("failed to close channel after error", "error", closeErr)
 			}
 		}
@@ -105,6 +107,9 @@ func (exchange *Exchange) Bind(client *Client, opts ExchangeBindOptions) error {
 		opts.NoWait,
 		opts.Args,
 	)
+	if err == nil {
+		client.amqpClient.topology.bindExchange(opts)
+	}
 	slog.Info("exchange binded", "destination", opts.Destination, "key", opts.Key, "source", opts.Source)
 	return err
 }
@@ -113,19 +118,19 @@ func (exchange *Exchange) Unbind(client *Client, opts ExchangeUnbindOptions) err
 	if client == nil {
 		return errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
 				slog.Error("failed to close channel after error", "error", closeErr)
 			}
 		}
@@ -137,6 +142,9 @@ func (exchange *Exchange) Unbind(client *Client, opts ExchangeUnbindOptions) err
 		opts.NoWait,
 		opts.Args,
 	)
+	if err == nil {
+		client.amqpClient.topology.unbindExchange(opts.Destination, opts.Key, opts.Source)
+	}
 	slog.Info("exchange binded", "destination", opts.Destination, "key", opts.Key, "source", opts.Source)
 	return err
 }
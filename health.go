@@ -0,0 +1,76 @@
+package k9amqp
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	evictionInterval = time.Second
+	rotationGrace    = 10 * time.Second
+)
+
+// maintain evicts expired idle channels and rotates pool connections in background.
+func (amqpClient *AmqpClient) maintain() {
+	poolOptions := amqpClient.poolOptions
+	var eviction, rotation <-chan time.Time
+	if amqpClient.channels.expires() {
+		ticker := time.NewTicker(evictionInterval)
+		eviction = ticker.C
+		defer ticker.Stop()
+	}
+	if poolOptions.rotateInterval > 0 {
+		ticker := time.NewTicker(poolOptions.rotateInterval)
+		rotation = ticker.C
+		defer ticker.Stop()
+	}
+	if eviction == nil && rotation == nil {
+		return
+	}
+	for idx := 0; ; {
+		select {
+		case <-amqpClient.done:
+			return
+		case <-eviction:
+			amqpClient.channels.evictExpired()
+		case <-rotation:
+			amqpClient.rotate(idx % len(amqpClient.channels.connections))
+			idx++
+		}
+	}
+}
+
+// rotate replaces idx-th pool connection by a new one to the preferred node, so connections
+// moved by failover get back. Old connection is closed after grace period to let in-flight
+// operations finish.
+func (amqpClient *AmqpClient) rotate(idx int) {
+	old := amqpClient.channels.connections[idx].Load()
+	if old.IsClosed() {
+		return
+	}
+	conn, err := amqpClient.connectNode(amqpClient.selector.candidates(idx), make(map[int]bool), amqpClient.scope)
+	if err != nil {
+		slog.Warn("pool connection rotation failed", "connection", idx, "error", err)
+		return
+	}
+	if amqpClient.isClosed() {
+		if err := conn.Close(); err != nil {
+			slog.Error("failed to close connection", "error", err)
+		}
+		return
+	}
+	if !amqpClient.channels.replace(idx, old, conn) {
+		slog.Debug("pool connection replaced during rotation", "connection", idx)
+		if err := conn.Close(); err != nil {
+			slog.Error("failed to close connection", "error", err)
+		}
+		return
+	}
+	amqpClient.watch(idx, conn)
+	slog.Info("pool connection rotated", "connection", idx, "from", old.endpoint.String(), "to", conn.endpoint.String())
+	time.AfterFunc(rotationGrace, func() {
+		if err := old.Close(); err != nil {
+			slog.Debug("failed to close rotated connection", "error", err)
+		}
+	})
+}
@@ -2,18 +2,48 @@
 
 // 1. Standalone/Global Types (No 'export' at the root level)
 interface Table { [key: string]: any; }
-interface AmqpOptions { host?: string; port?: number; vhost?: string; username?: string; password?: string; }
-interface PoolOptions { channels_per_conn?: number; channels_cache_size?: number; }
+interface NodeOptions { uri?: string; host?: string; port?: number; weight?: number; }
+interface AuthOptions { mechanism?: 'PLAIN' | 'AMQPLAIN' | 'EXTERNAL' | string; params?: { [key: string]: string }; }
+interface TLSOptions { enabled?: boolean; ca_file?: string; cert_file?: string; key_file?: string; server_name?: string; min_version?: '1.0' | '1.1' | '1.2' | '1.3'; insecure_skip_verify?: boolean; }
+interface AmqpOptions { name?: string; uri?: string; uris?: string[]; nodes?: NodeOptions[]; host?: string; port?: number; vhost?: string; username?: string; password?: string; tls?: TLSOptions; auth?: AuthOptions; heartbeat?: string; frame_max?: number; channel_max?: number; dial_timeout?: string; locale?: string; connection_name?: string; client_properties?: Table; }
+interface RecoveryOptions { disabled?: boolean; initial_interval?: string; max_interval?: string; multiplier?: number; max_attempts?: number; topology?: boolean; }
+interface PoolOptions { channels_per_conn?: number; channels_cache_size?: number; connections?: number; distribution?: 'round_robin' | 'random' | 'weighted'; recovery?: RecoveryOptions; max_channels?: number; acquire_timeout?: string; warm_up?: number; max_channel_age?: string; idle_timeout?: string; rotate_interval?: string; mode?: 'shared' | 'per_vu' | 'per_iteration'; confirm?: boolean; async_confirm?: boolean; confirm_window?: number; }
 interface Publishing { content_type?: string; content_encoding?: string; delivery_mode?: number; priority?: number; correlation_id?: string; reply_to?: string; expiration?: string; message_id?: string; timestamp?: Date; type?: string; user_id?: string; app_id?: string; headers?: Table; body: string | ArrayBuffer; }
 interface Delivery { Acknowledger: any; Headers: Table; ContentType: string; ContentEncoding: string; DeliveryMode: number; Priority: number; CorrelationId: string; ReplyTo: string; Expiration: string; MessageId: string; Timestamp: Date; Type: string; UserId: string; AppId: string; ConsumerTag: string; MessageCount: number; DeliveryTag: number; Redelivered: boolean; Exchange: string; RoutingKey: string; Body: string | ArrayBuffer; }
-interface PublishOptions { exchange: string; key: string; mandatory?: boolean; immediate?: boolean; }
-interface AmqpProduceResponse { Error: boolean; ErrorMessage: string; }
+interface PublishOptions { exchange: string; key: string; mandatory?: boolean; immediate?: boolean; timeout?: string; confirm?: boolean; body?: BodyGenerator; template?: boolean; timestamp?: boolean; track?: boolean; }
+interface AmqpProduceResponse { Error: boolean; ErrorMessage: string; Confirmed: boolean; Acked: boolean; Returned: boolean; }
+interface BodyOptions { kind?: 'bytes' | 'ascii' | 'file'; size?: number; distribution?: 'fixed' | 'uniform' | 'normal' | 'histogram'; min?: number; max?: number; mean?: number; std_dev?: number; histogram?: string; seed?: number; file?: string; }
+interface BodyGenerator { next(): ArrayBuffer; }
+interface BatchMessage extends Omit<Publishing, 'body'> { body?: string | ArrayBuffer; exchange?: string; key?: string; }
+interface AmqpBatchResponse { Error: boolean; ErrorMessage: string; Sent: number; Failed: number; Nacked: number; Returned: number; Results: AmqpProduceResponse[]; }
 interface GetOptions { queue: string; auto_ack: boolean; }
 interface AmqpGetResponse { Delivery: Delivery; Ok: boolean; Error: boolean; ErrorMessage: string; }
 interface ConsumeOptions { queue: string; auto_ack: boolean; exclusive?: boolean; no_local?: boolean; no_wait?: boolean; args?: Table; size: number; }
 interface AmqpConsumeResponse { Deliveries: Delivery[]; Ok: boolean; Error: boolean; ErrorMessage: string; }
 interface ListenOptions { queue: string; auto_ack: boolean; exclusive?: boolean; no_local?: boolean; no_wait?: boolean; args?: Table; }
 type ListenerType = (delivery: Delivery) => void | Error;
+interface Channel {
+  publish(opts: PublishOptions, msg: Publishing): AmqpProduceResponse;
+  get(opts: GetOptions): AmqpGetResponse;
+  consume(opts: ConsumeOptions): AmqpConsumeResponse;
+  ack(deliveryTag: number, multiple: boolean): void;
+  nack(deliveryTag: number, multiple: boolean, requeue: boolean): void;
+  qos(prefetchCount: number, prefetchSize: number, global: boolean): void;
+  close(): void;
+}
+interface Transaction extends Channel {
+  commit(): void;
+  rollback(): void;
+}
+type TransactionFunc = (tx: Transaction) => void;
+interface ProducerOptions extends PublishOptions { profile?: 'constant' | 'ramp' | 'step' | 'sine' | 'burst'; rate: number; target_rate?: number; step?: number; amplitude?: number; burst_rate?: number; duration?: string; period?: string; burst_duration?: string; }
+interface Producer {
+  stop(): void;
+  wait(): void;
+  running(): boolean;
+  sent(): number;
+  failed(): number;
+}
 interface QueueDeclareOptions { name: string; durable?: boolean; auto_delete?: boolean; exclusive?: boolean; no_wait?: boolean; passive?: boolean; args?: Table; }
 interface Queue { Name: string; Messages: number; Consumers: number; }
 interface QueueDeleteOptions { name: string; if_unused?: boolean; if_empty?: boolean; no_wait?: boolean; }
@@ -30,11 +60,20 @@ declare module 'k6/x/k9amqp' {
   export class Client {
     constructor(amqpOptions?: AmqpOptions, poolOptions?: PoolOptions);
     publish(opts: PublishOptions, msg: Publishing): AmqpProduceResponse;
+    publishBatch(opts: PublishOptions, messages: BatchMessage[]): AmqpBatchResponse;
+    transaction(fn: TransactionFunc): void;
+    channel(): Channel;
+    startProducer(opts: ProducerOptions, msg?: Partial<Publishing>): Producer;
     get(opts: GetOptions): AmqpGetResponse;
     consume(opts: ConsumeOptions): AmqpConsumeResponse;
     listen(opts: ListenOptions, listener: ListenerType): void;
     teardown(): void;
   }
+
+  export class BodyGenerator {
+    constructor(opts?: BodyOptions);
+    next(): ArrayBuffer;
+  }
 }
 
 // 3. Queue Module
@@ -1,10 +1,14 @@
 package k9amqp
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/sobek"
@@ -13,9 +17,6 @@ import (
 	"go.k6.io/k6/v2/metrics"
 )
 
-var amqpClient *AmqpClient
-var once sync.Once
-
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
 var seededRand *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -28,6 +29,12 @@ type K9amqp struct {
 type Client struct {
 	amqpClient *AmqpClient
 	k9amqp     K9amqp
+	vuConn     vuConnection
+	handles    []*Channel
+	seq        atomic.Uint64
+	track      *trackSource
+	iterations sync.Once
+	connEvents sync.Once
 }
 
 func (k9amqp *K9amqp) XClient(call sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
@@ -43,8 +50,6 @@ func (k9amqp *K9amqp) XClient(call sobek.ConstructorCall, rt *sobek.Runtime) *so
 			panic(rt.NewTypeError("failed to export poolOptions: %v", err))
 		}
 	}
-	amqpOptions.init()
-	poolOptions.init()
 	client := &Client{k9amqp: *k9amqp}
 	if err := client.init(*amqpOptions, *poolOptions); err != nil {
 		panic(rt.NewGoError(err))
@@ -52,57 +57,151 @@ func (k9amqp *K9amqp) XClient(call sobek.ConstructorCall, rt *sobek.Runtime) *so
 	return rt.ToValue(client).ToObject(rt)
 }
 
-func (client *Client) getAmqpClient(amqpOptions AmqpOptions, poolOptions PoolOptions) (*AmqpClient, error) {
-	var err error
-	once.Do(func() {
-		slog.Info(fmt.Sprintf("init amqp client with pool %+v\n", poolOptions))
-		amqpClient = &AmqpClient{amqpOptions: amqpOptions, poolOptions: poolOptions}
-		err = amqpClient.init()
-	})
-	return amqpClient, err
-}
-
 func (client *Client) init(amqpOptions AmqpOptions, poolOptions PoolOptions) error {
 	var err error
-	amqpClient, err := client.getAmqpClient(amqpOptions, poolOptions)
+	amqpClient, err := sharedAmqpClient(amqpOptions, poolOptions, client.scope(amqpOptions))
 	client.amqpClient = amqpClient
 	return err
 }
 
+// scope names connections of the client, it's the scenario when the client is created by a VU,
+// client name or 'shared' when created in init context.
+func (client *Client) scope(amqpOptions AmqpOptions) string {
+	if client.k9amqp.vu.State() != nil {
+		if scenario, ok := client.k9amqp.scenario(); ok {
+			return *scenario
+		}
+	}
+	if amqpOptions.Name != "" {
+		return amqpOptions.Name
+	}
+	return "shared"
+}
+
+// connScope names connections the client opens for the calling VU by the VU's scenario, pool
+// connections opened in init context keep the scope of the client.
+func (client *Client) connScope() string {
+	if client.k9amqp.vu.State() != nil {
+		if scenario, ok := client.k9amqp.scenario(); ok {
+			return *scenario
+		}
+	}
+	return client.amqpClient.scope
+}
+
 func (client *Client) Teardown() {
 	slog.Info("Teardown AMQP Client")
+	client.k9amqp.reportTracked()
+	client.vuConn.mutex.Lock()
+	client.vuConn.close()
+	client.vuConn.mutex.Unlock()
+	releaseAmqpClient(client.amqpClient)
 	if err := client.amqpClient.close(); err != nil {
 		slog.Error("failed to close amqp client(s)")
 	}
 }
 
+// context returns the VU context, or background context outside of VU.
+func (client *Client) context() context.Context {
+	if ctx := client.k9amqp.vu.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// acquire gets pooled channel, or channel of the VU's own connection in per_vu and
+// per_iteration modes, and reports pool metrics.
+func (client *Client) acquire() (*amqpChannel, error) {
+	client.watchIterations()
+	client.releaseHandles()
+	client.watchConnEvents()
+	client.k9amqp.reportPoolStats(client.amqpClient)
+	client.k9amqp.reportReturns(client.amqpClient)
+	ctx := client.context()
+	if client.amqpClient.dedicated() {
+		channels, err := client.dedicatedPool()
+		if err != nil {
+			return nil, err
+		}
+		return channels.get(ctx)
+	}
+	startTime := time.Now()
+	channel, err := client.amqpClient.channels.get(ctx)
+	if client.amqpClient.channels.bounded() {
+		client.k9amqp.reportPoolMetrics(time.Since(startTime), errors.Is(err, errPoolExhausted))
+	}
+	return channel, err
+}
+
 func (client *Client) Publish(opts PublishOptions, msg amqp.Publishing) (AmqpProduceResponse, error) {
 	var err error
-	var duration time.Duration
-	channel, err := client.amqpClient.channels.get()
+	if err = opts.init(); err != nil {
+		return AmqpProduceResponse{Error: true, ErrorMessage: err.Error()}, err
+	}
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return AmqpProduceResponse{Error: true, ErrorMessage: err.Error()}, err
 	}
 	defer func() {
-		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
-				slog.Error("failed to return channel to pool", "error", putErr)
-			}
-		} else {
-			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
-				slog.Error("failed to close channel after error", "error", closeErr)
-			}
-		}
+		client.releasePublished(channel, err)
 	}()
-	duration, err = client.publish(channel, opts, msg)
+	var response AmqpProduceResponse
+	response, err = client.publishOn(channel, opts, msg)
+	return response, err
+}
+
+// publishOn publishes on the channel in confirm mode selected by options, opts are initialized by the caller.
+func (client *Client) publishOn(channel *amqpChannel, opts PublishOptions, msg amqp.Publishing) (AmqpProduceResponse, error) {
+	var err error
+	var duration time.Duration
+	startTime := time.Now()
+	async := client.amqpClient.poolOptions.AsyncConfirm && !channel.transactional
+	confirm := (opts.Confirm || client.amqpClient.poolOptions.Confirm || async) && !channel.transactional
+	if confirm {
+		err = channel.confirmMode()
+	}
+	var confirmation *amqp.DeferredConfirmation
+	var acked, returned bool
+	var confirmLatency time.Duration
+	switch {
+	case err != nil:
+	case async:
+		tracker := channel.tracker(client.amqpClient.poolOptions.ConfirmWindow)
+		if err = tracker.reserve(client.context(), opts.timeout); err != nil {
+			break
+		}
+		duration, confirmation, err = client.publish(channel, opts, msg)
+		if err != nil {
+			tracker.release()
+			break
+		}
+		go tracker.await(confirmation, startTime, client.k9amqp.confirmReporter(channel.endpoint, opts))
+	default:
+		var returnID uint64
+		var awaitReturn bool
+		if opts.Mandatory && confirm {
+			msg, returnID, awaitReturn = channel.awaitReturn(msg)
+		}
+		duration, confirmation, err = client.publish(channel, opts, msg)
+		if err == nil && confirm {
+			acked, err = client.waitConfirm(confirmation, opts)
+			confirmLatency = time.Since(startTime)
+		}
+		switch {
+		case !awaitReturn:
+		case err == nil:
+			returned = channel.returns.wasReturned(returnID)[0]
+		default:
+			channel.returns.forget(returnID)
+		}
+	}
 	var errMessage string
 	if err != nil {
 		errMessage = err.Error()
 	}
-	response := AmqpProduceResponse{Error: err != nil, ErrorMessage: errMessage}
-	if metricsErr := client.k9amqp.reportPublishMetrics(client, opts, response, duration); metricsErr != nil {
+	response := AmqpProduceResponse{Error: err != nil, ErrorMessage: errMessage, Confirmed: confirm && !async && err == nil, Acked: acked, Returned: returned}
+	if metricsErr := client.k9amqp.reportPublishMetrics(channel.endpoint, opts, response, duration, confirmLatency); metricsErr != nil {
 		slog.Error("failed to report publish metrics", "error", metricsErr)
 	}
 	if err != nil {
@@ -111,50 +210,149 @@ func (client *Client) Publish(opts PublishOptions, msg amqp.Publishing) (AmqpPro
 	return response, nil
 }
 
-func (*Client) publish(channel *amqp.Channel, opts PublishOptions, msg amqp.Publishing) (time.Duration, error) {
+// releasePublished returns publishing channel to the pool unless the publish failed on the channel.
+func (client *Client) releasePublished(channel *amqpChannel, err error) {
+	switch {
+	case errors.Is(err, errPublishTimeout):
+		// channel is closed once the pending publish returns
+	case err == nil || errors.Is(err, errBlocked) || errors.Is(err, errConfirmWindowFull) || errors.Is(err, errTemplate):
+		if putErr := channel.pool.put(channel, nil); putErr != nil {
+			slog.Error("failed to return channel to pool", "error", putErr)
+		}
+	default:
+		slog.Info("blows channel after error")
+		if closeErr := channel.blow(); closeErr != nil {
+			slog.Error("failed to close channel after error", "error", closeErr)
+		}
+	}
+}
+
+func (opts *PublishOptions) init() error {
+	if opts.Timeout == "" {
+		return nil
+	}
+	var err error
+	if opts.timeout, err = time.ParseDuration(opts.Timeout); err != nil {
+		return fmt.Errorf("invalid publish timeout: %w", err)
+	}
+	return nil
+}
+
+// publish sends the message, with timeout it fails fast when the connection is blocked by broker
+// and gives up waiting for a publish stuck on a blocked socket. Confirmation is nil unless
+// the channel is in confirm mode.
+func (client *Client) publish(channel *amqpChannel, opts PublishOptions, msg amqp.Publishing) (time.Duration, *amqp.DeferredConfirmation, error) {
+	if opts.Template {
+		var err error
+		if msg, err = renderMessage(client.templateContext(opts.origin), msg); err != nil {
+			return 0, nil, fmt.Errorf("%w: %w", errTemplate, err)
+		}
+	}
+	if opts.Body != nil && len(msg.Body) == 0 {
+		msg.Body = opts.Body.next()
+	}
 	startTime := time.Now()
-	err := channel.Publish(
-		opts.Exchange,
-		opts.Key,
-		opts.Mandatory,
-		opts.Immediate,
-		msg,
-	)
-	duration := time.Since(startTime)
-	return duration, err
+	if opts.Timestamp {
+		msg.Headers = stampSent(msg.Headers, startTime)
+	}
+	var source *trackSource
+	if opts.Track {
+		if source = opts.source; source == nil {
+			if client.track == nil {
+				client.track = tracker.newSource()
+			}
+			source = client.track
+		}
+		msg.Headers = source.stamp(msg.Headers, channel.id)
+	}
+	type sent struct {
+		confirmation *amqp.DeferredConfirmation
+		err          error
+	}
+	send := func() sent {
+		confirmation, err := channel.PublishWithDeferredConfirm(
+			opts.Exchange,
+			opts.Key,
+			opts.Mandatory,
+			opts.Immediate,
+			msg,
+		)
+		if err == nil && source != nil {
+			source.sent.Add(1)
+		}
+		return sent{confirmation, err}
+	}
+	if opts.timeout <= 0 {
+		result := send()
+		return time.Since(startTime), result.confirmation, result.err
+	}
+	deadline := time.NewTimer(opts.timeout)
+	defer deadline.Stop()
+	if err := channel.conn.waitUnblocked(deadline.C); err != nil {
+		return time.Since(startTime), nil, fmt.Errorf("%w, not unblocked within %s", err, opts.Timeout)
+	}
+	done := make(chan sent, 1)
+	go func() {
+		done <- send()
+	}()
+	select {
+	case result := <-done:
+		return time.Since(startTime), result.confirmation, result.err
+	case <-deadline.C:
+		go func() {
+			<-done
+			if closeErr := channel.blow(); closeErr != nil {
+				slog.Debug("failed to close channel after publish timeout", "error", closeErr)
+			}
+		}()
+		err := fmt.Errorf("%w after %s", errPublishTimeout, opts.Timeout)
+		if blockedErr := channel.conn.blockedErr(); blockedErr != nil {
+			err = fmt.Errorf("%w: %w", err, blockedErr)
+		}
+		return time.Since(startTime), nil, err
+	}
 }
 
 func (client *Client) Get(opts GetOptions) (AmqpGetResponse, error) {
 	var err error
-	var delivery amqp.Delivery
-	var ok bool
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return AmqpGetResponse{Error: true}, err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
 				slog.Error("failed to close channel after error", "error", closeErr)
 			}
 		}
 	}()
-	delivery, ok, err = channel.Get(
+	var response AmqpGetResponse
+	response, err = client.getOn(channel, opts)
+	return response, err
+}
+
+func (client *Client) getOn(channel *amqpChannel, opts GetOptions) (AmqpGetResponse, error) {
+	startTime := time.Now()
+	delivery, ok, err := channel.Get(
 		opts.Queue,
 		opts.AutoAck,
 	)
+	duration := time.Since(startTime)
 	var errorMessage string
 	if err != nil {
 		errorMessage = err.Error()
 	}
 	response := AmqpGetResponse{Delivery: delivery, Ok: ok, Error: err != nil, ErrorMessage: errorMessage}
-	if metricsErr := client.k9amqp.reportGetMetrics(client, response); metricsErr != nil {
+	if ok {
+		client.k9amqp.track([]amqp.Delivery{delivery})
+	}
+	if metricsErr := client.k9amqp.reportGetMetrics(channel.endpoint, opts.Queue, response, duration); metricsErr != nil {
 		slog.Error("failed to report get metrics", "error", metricsErr)
 	}
 	if err != nil || !ok {
@@ -165,28 +363,37 @@ func (client *Client) Get(opts GetOptions) (AmqpGetResponse, error) {
 
 func (client *Client) Consume(opts ConsumeOptions) (AmqpConsumeResponse, error) {
 	var err error
-	var consumerTag = randString(10)
-	deliveries := []amqp.Delivery{}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return AmqpConsumeResponse{Error: true}, err
 	}
 	defer func() {
-		if cancelErr := channel.Cancel(consumerTag, opts.NoWait); cancelErr != nil {
-			slog.Error("failed to cancel consumer", "error", cancelErr)
-		}
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
 				slog.Error("failed to close channel after error", "error", closeErr)
 			}
 		}
 	}()
+	var response AmqpConsumeResponse
+	response, err = client.consumeOn(channel, opts)
+	return response, err
+}
+
+func (client *Client) consumeOn(channel *amqpChannel, opts ConsumeOptions) (AmqpConsumeResponse, error) {
+	var consumerTag = randString(10)
+	deliveries := []amqp.Delivery{}
+	startTime := time.Now()
+	defer func() {
+		if cancelErr := channel.Cancel(consumerTag, opts.NoWait); cancelErr != nil {
+			slog.Error("failed to cancel consumer", "error", cancelErr)
+		}
+	}()
 	amqpChannel, err := channel.Consume(
 		opts.Queue,
 		consumerTag,
@@ -215,7 +422,8 @@ func (client *Client) Consume(opts ConsumeOptions) (AmqpConsumeResponse, error)
 		errorMessage = err.Error()
 	}
 	response := AmqpConsumeResponse{Deliveries: deliveries, Ok: len(deliveries) > 0, Error: err != nil, ErrorMessage: errorMessage}
-	if metricsErr := client.k9amqp.reportConsumeMetrics(client, response); metricsErr != nil {
+	client.k9amqp.track(deliveries)
+	if metricsErr := client.k9amqp.reportConsumeMetrics(channel.endpoint, opts.Queue, response, time.Since(startTime)); metricsErr != nil {
 		slog.Error("failed to report consume metrics", "error", metricsErr)
 	}
 	if err != nil || len(deliveries) == 0 {
@@ -227,7 +435,7 @@ func (client *Client) Consume(opts ConsumeOptions) (AmqpConsumeResponse, error)
 func (client *Client) Listen(opts ListenOptions, listener ListenerType) error {
 	var err error
 	var consumerTag = randString(10)
-	conn, err := client.amqpClient.Connect()
+	conn, err := client.amqpClient.Connect(client.connScope())
 	if err != nil {
 		slog.Error("unable to get amqp connection")
 		return err
@@ -257,6 +465,8 @@ func (client *Client) Listen(opts ListenOptions, listener ListenerType) error {
 		return err
 	}
 
+	reportE2E := client.k9amqp.e2eReporter(opts.Queue)
+	reportTrack := client.k9amqp.trackReporter()
 	go func() {
 		defer func() {
 			if cancelErr := channel.Cancel(consumerTag, opts.NoWait); cancelErr != nil {
@@ -270,6 +480,10 @@ func (client *Client) Listen(opts ListenOptions, listener ListenerType) error {
 			}
 		}()
 		for d := range amqpChannel {
+			reportE2E(d)
+			if result := tracker.receive([]amqp.Delivery{d}); result != (trackResult{}) {
+				reportTrack(result)
+			}
 			if err := listener(d); err != nil {
 				slog.Error(fmt.Sprintf("%s (Listen)", err.Error()))
 				return
@@ -279,13 +493,17 @@ func (client *Client) Listen(opts ListenOptions, listener ListenerType) error {
 	return nil
 }
 
-func (k9amqp *K9amqp) reportPublishMetrics(client *Client, opts PublishOptions, resp AmqpProduceResponse, duration time.Duration) error {
-	now := time.Now()
+func (k9amqp *K9amqp) reportPublishMetrics(endpoint amqpEndpoint, opts PublishOptions, resp AmqpProduceResponse, duration, confirmLatency time.Duration) error {
 	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
-	tags := ctm.Tags.With("endpoint", fmt.Sprintf("amqp://%s@%s:%d/%s", client.amqpClient.amqpOptions.Username, client.amqpClient.amqpOptions.Host, client.amqpClient.amqpOptions.Port, client.amqpClient.amqpOptions.Vhost))
+	tags := ctm.Tags.With("endpoint", endpoint.String())
 	tags = tags.With("exchange", opts.Exchange)
 	tags = tags.With("routing_key", opts.Key)
-	ctx := k9amqp.vu.Context()
+	samples := k9amqp.publishSamples(time.Now(), tags, ctm.Metadata, resp, duration, confirmLatency)
+	metrics.PushIfNotDone(k9amqp.vu.Context(), k9amqp.vu.State().Samples, metrics.ConnectedSamples{Samples: samples})
+	return nil
+}
+
+func (k9amqp *K9amqp) publishSamples(now time.Time, tags *metrics.TagSet, metadata map[string]string, resp AmqpProduceResponse, duration, confirmLatency time.Duration) []metrics.Sample {
 	var sent int
 	var failed int
 	if resp.Error {
@@ -293,35 +511,144 @@ func (k9amqp *K9amqp) reportPublishMetrics(client *Client, opts PublishOptions,
 	} else {
 		sent = 1
 	}
-	metrics.PushIfNotDone(ctx, k9amqp.vu.State().Samples, metrics.ConnectedSamples{
-		Samples: []metrics.Sample{
-			{
-				Time: now,
-				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.PublishSent,
-					Tags:   tags,
-				},
-				Value:    float64(sent),
-				Metadata: ctm.Metadata,
+	samples := []metrics.Sample{
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishSent,
+				Tags:   tags,
 			},
-			{
-				Time: now,
-				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.PublishFailed,
-					Tags:   tags,
-				},
-				Value:    float64(failed),
-				Metadata: ctm.Metadata,
+			Value:    float64(sent),
+			Metadata: metadata,
+		},
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishFailed,
+				Tags:   tags,
 			},
+			Value:    float64(failed),
+			Metadata: metadata,
 		},
-	})
+	}
+	if !resp.Error {
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishLatency,
+				Tags:   tags,
+			},
+			Value:    metrics.D(duration),
+			Metadata: metadata,
+		})
+	}
+	if resp.Confirmed {
+		samples = append(samples, k9amqp.confirmSamples(now, tags, metadata, confirmLatency, resp.Acked)...)
+	}
+	return samples
+}
+
+// confirmReporter captures VU metrics context of a publish, so its confirm is reported when it arrives.
+func (k9amqp *K9amqp) confirmReporter(endpoint amqpEndpoint, opts PublishOptions) func(time.Duration, bool) {
+	state := k9amqp.vu.State()
+	if state == nil {
+		return func(time.Duration, bool) {}
+	}
+	ctx := k9amqp.vu.Context()
+	ctm := state.Tags.GetCurrentValues()
+	tags := ctm.Tags.With("endpoint", endpoint.String())
+	tags = tags.With("exchange", opts.Exchange)
+	tags = tags.With("routing_key", opts.Key)
+	return func(latency time.Duration, acked bool) {
+		samples := k9amqp.confirmSamples(time.Now(), tags, ctm.Metadata, latency, acked)
+		metrics.PushIfNotDone(ctx, state.Samples, metrics.ConnectedSamples{Samples: samples})
+	}
+}
+
+func (k9amqp *K9amqp) confirmSamples(now time.Time, tags *metrics.TagSet, metadata map[string]string, latency time.Duration, acked bool) []metrics.Sample {
+	var nacked float64
+	if !acked {
+		nacked = 1
+	}
+	return []metrics.Sample{
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConfirmLatency,
+				Tags:   tags,
+			},
+			Value:    metrics.D(latency),
+			Metadata: metadata,
+		},
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishNacked,
+				Tags:   tags,
+			},
+			Value:    nacked,
+			Metadata: metadata,
+		},
+	}
+}
+
+func (k9amqp *K9amqp) reportBatchMetrics(endpoint amqpEndpoint, opts PublishOptions, resp AmqpBatchResponse, confirmLatencies []time.Duration) error {
+	now := time.Now()
+	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
+	tags := ctm.Tags.With("endpoint", endpoint.String())
+	tags = tags.With("exchange", opts.Exchange)
+	tags = tags.With("routing_key", opts.Key)
+	ctx := k9amqp.vu.Context()
+	samples := []metrics.Sample{
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishSent,
+				Tags:   tags,
+			},
+			Value:    float64(resp.Sent),
+			Metadata: ctm.Metadata,
+		},
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishFailed,
+				Tags:   tags,
+			},
+			Value:    float64(resp.Failed),
+			Metadata: ctm.Metadata,
+		},
+	}
+	for _, latency := range confirmLatencies {
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConfirmLatency,
+				Tags:   tags,
+			},
+			Value:    metrics.D(latency),
+			Metadata: ctm.Metadata,
+		})
+	}
+	if len(confirmLatencies) > 0 {
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishNacked,
+				Tags:   tags,
+			},
+			Value:    float64(resp.Nacked),
+			Metadata: ctm.Metadata,
+		})
+	}
+	metrics.PushIfNotDone(ctx, k9amqp.vu.State().Samples, metrics.ConnectedSamples{Samples: samples})
 	return nil
 }
 
-func (k9amqp *K9amqp) reportGetMetrics(client *Client, resp AmqpGetResponse) error {
+func (k9amqp *K9amqp) reportGetMetrics(endpoint amqpEndpoint, queue string, resp AmqpGetResponse, duration time.Duration) error {
 	now := time.Now()
 	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
-	tags := ctm.Tags.With("endpoint", fmt.Sprintf("amqp://%s@%s:%d/%s", client.amqpClient.amqpOptions.Username, client.amqpClient.amqpOptions.Host, client.amqpClient.amqpOptions.Port, client.amqpClient.amqpOptions.Vhost))
+	tags := ctm.Tags.With("endpoint", endpoint.String())
 	ctx := k9amqp.vu.Context()
 	tags = k9amqp.metricsTags(tags, &resp.Delivery)
 	var received int
@@ -334,48 +661,61 @@ func (k9amqp *K9amqp) reportGetMetrics(client *Client, resp AmqpGetResponse) err
 	} else if !resp.Ok {
 		noDelivery = 1
 	}
-	metrics.PushIfNotDone(ctx, k9amqp.vu.State().Samples, metrics.ConnectedSamples{
-		Samples: []metrics.Sample{
-			{
-				Time: now,
-				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.ConsumeReceived,
-					Tags:   tags,
-				},
-				Value:    float64(received),
-				Metadata: ctm.Metadata,
+	samples := []metrics.Sample{
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeReceived,
+				Tags:   tags,
 			},
-			{
-				Time: now,
-				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.ConsumeNoDelivery,
-					Tags:   tags,
-				},
-				Value:    float64(noDelivery),
-				Metadata: ctm.Metadata,
+			Value:    float64(received),
+			Metadata: ctm.Metadata,
+		},
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeNoDelivery,
+				Tags:   tags,
 			},
-			{
-				Time: now,
-				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.ConsumeFailed,
-					Tags:   tags,
-				},
-				Value:    float64(failed),
-				Metadata: ctm.Metadata,
+			Value:    float64(noDelivery),
+			Metadata: ctm.Metadata,
+		},
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeFailed,
+				Tags:   tags,
 			},
+			Value:    float64(failed),
+			Metadata: ctm.Metadata,
 		},
-	})
+	}
+	if !resp.Error {
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeLatency,
+				Tags:   tags.With("queue", queue),
+			},
+			Value:    metrics.D(duration),
+			Metadata: ctm.Metadata,
+		})
+	}
+	if resp.Ok {
+		samples = append(samples, k9amqp.e2eSamples(now, ctm.Tags.With("endpoint", endpoint.String()), ctm.Metadata, queue, []amqp.Delivery{resp.Delivery})...)
+	}
+	metrics.PushIfNotDone(ctx, k9amqp.vu.State().Samples, metrics.ConnectedSamples{Samples: samples})
 	return nil
 }
 
-func (k9amqp *K9amqp) reportConsumeMetrics(client *Client, resp AmqpConsumeResponse) error {
+func (k9amqp *K9amqp) reportConsumeMetrics(endpoint amqpEndpoint, queue string, resp AmqpConsumeResponse, duration time.Duration) error {
 	now := time.Now()
 	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
 	var delivery *amqp.Delivery
 	if len(resp.Deliveries) > 0 {
 		delivery = &resp.Deliveries[0]
 	}
-	tags := ctm.Tags.With("endpoint", fmt.Sprintf("amqp://%s@%s:%d/%s", client.amqpClient.amqpOptions.Username, client.amqpClient.amqpOptions.Host, client.amqpClient.amqpOptions.Port, client.amqpClient.amqpOptions.Vhost))
+	tags := ctm.Tags.With("endpoint", endpoint.String())
 	tags = k9amqp.metricsTags(tags, delivery)
 	ctx := k9amqp.vu.Context()
 	var noDelivery int
@@ -386,38 +726,240 @@ func (k9amqp *K9amqp) reportConsumeMetrics(client *Client, resp AmqpConsumeRespo
 	} else if !resp.Ok {
 		noDelivery = 1
 	}
-	metrics.PushIfNotDone(ctx, k9amqp.vu.State().Samples, metrics.ConnectedSamples{
-		Samples: []metrics.Sample{
-			{
-				Time: now,
-				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.ConsumeReceived,
-					Tags:   tags,
-				},
-				Value:    float64(received),
-				Metadata: ctm.Metadata,
+	samples := []metrics.Sample{
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeReceived,
+				Tags:   tags,
 			},
-			{
-				Time: now,
-				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.ConsumeNoDelivery,
-					Tags:   tags,
-				},
-				Value:    float64(noDelivery),
-				Metadata: ctm.Metadata,
+			Value:    float64(received),
+			Metadata: ctm.Metadata,
+		},
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeNoDelivery,
+				Tags:   tags,
+			},
+			Value:    float64(noDelivery),
+			Metadata: ctm.Metadata,
+		},
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeFailed,
+				Tags:   tags,
 			},
-			{
+			Value:    float64(failed),
+			Metadata: ctm.Metadata,
+		},
+	}
+	if !resp.Error {
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.ConsumeLatency,
+				Tags:   tags.With("queue", queue),
+			},
+			Value:    metrics.D(duration),
+			Metadata: ctm.Metadata,
+		})
+	}
+	if resp.Ok {
+		samples = append(samples, k9amqp.e2eSamples(now, ctm.Tags.With("endpoint", endpoint.String()), ctm.Metadata, queue, resp.Deliveries)...)
+	}
+	metrics.PushIfNotDone(ctx, k9amqp.vu.State().Samples, metrics.ConnectedSamples{Samples: samples})
+	return nil
+}
+
+// reportTxMetrics pushes commit latency of committed transaction or counts rolled back one.
+func (k9amqp *K9amqp) reportTxMetrics(endpoint amqpEndpoint, latency time.Duration, err error, rollback bool) {
+	if k9amqp.vu.State() == nil || err != nil {
+		return
+	}
+	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
+	sample := metrics.Sample{
+		Time: time.Now(),
+		TimeSeries: metrics.TimeSeries{
+			Metric: k9amqp.metrics.TxCommitLatency,
+			Tags:   ctm.Tags.With("endpoint", endpoint.String()),
+		},
+		Value:    metrics.D(latency),
+		Metadata: ctm.Metadata,
+	}
+	if rollback {
+		sample.Metric, sample.Value = k9amqp.metrics.TxRollback, 1
+	}
+	metrics.PushIfNotDone(k9amqp.vu.Context(), k9amqp.vu.State().Samples, sample)
+}
+
+func (k9amqp *K9amqp) reportPoolMetrics(wait time.Duration, exhausted bool) {
+	if k9amqp.vu.State() == nil {
+		return
+	}
+	now := time.Now()
+	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
+	samples := []metrics.Sample{
+		{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PoolWait,
+				Tags:   ctm.Tags,
+			},
+			Value:    metrics.D(wait),
+			Metadata: ctm.Metadata,
+		},
+	}
+	if exhausted {
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PoolExhausted,
+				Tags:   ctm.Tags,
+			},
+			Value:    1,
+			Metadata: ctm.Metadata,
+		})
+	}
+	metrics.PushIfNotDone(k9amqp.vu.Context(), k9amqp.vu.State().Samples, metrics.ConnectedSamples{Samples: samples})
+}
+
+func (k9amqp *K9amqp) reportConnectMetrics(endpoint amqpEndpoint, duration time.Duration) {
+	if k9amqp.vu.State() == nil {
+		return
+	}
+	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
+	metrics.PushIfNotDone(k9amqp.vu.Context(), k9amqp.vu.State().Samples, metrics.Sample{
+		Time: time.Now(),
+		TimeSeries: metrics.TimeSeries{
+			Metric: k9amqp.metrics.ConnectDuration,
+			Tags:   ctm.Tags.With("endpoint", endpoint.String()),
+		},
+		Value:    metrics.D(duration),
+		Metadata: ctm.Metadata,
+	})
+}
+
+// reportPoolStats pushes pool gauges and channel counters tagged by node, the shared pool is
+// empty in per_vu and per_iteration modes.
+func (k9amqp *K9amqp) reportPoolStats(amqpClient *AmqpClient) {
+	if k9amqp.vu.State() == nil || amqpClient.dedicated() {
+		return
+	}
+	snapshot := amqpClient.channels.snapshot(amqpClient.amqpOptions.endpoints)
+	if snapshot == nil {
+		return
+	}
+	now := time.Now()
+	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
+	samples := make([]metrics.Sample, 0, 4*len(snapshot))
+	for _, stats := range snapshot {
+		tags := ctm.Tags.With("endpoint", stats.endpoint.String())
+		for metric, value := range map[*metrics.Metric]float64{
+			k9amqp.metrics.Connections:       float64(stats.connections),
+			k9amqp.metrics.ChannelsIdle:      float64(stats.idle),
+			k9amqp.metrics.ChannelsOpened:    float64(stats.opened),
+			k9amqp.metrics.ChannelsErrClosed: float64(stats.closedOnError),
+		} {
+			samples = append(samples, metrics.Sample{
 				Time: now,
 				TimeSeries: metrics.TimeSeries{
-					Metric: k9amqp.metrics.ConsumeFailed,
+					Metric: metric,
 					Tags:   tags,
 				},
-				Value:    float64(failed),
+				Value:    value,
 				Metadata: ctm.Metadata,
+			})
+		}
+	}
+	metrics.PushIfNotDone(k9amqp.vu.Context(), k9amqp.vu.State().Samples, metrics.ConnectedSamples{Samples: samples})
+}
+
+// reportReturns pushes messages returned by broker since the last call.
+func (k9amqp *K9amqp) reportReturns(amqpClient *AmqpClient) {
+	if k9amqp.vu.State() == nil {
+		return
+	}
+	counts := amqpClient.returns.drain()
+	if len(counts) == 0 {
+		return
+	}
+	now := time.Now()
+	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
+	samples := make([]metrics.Sample, 0, len(counts))
+	for key, count := range counts {
+		tags := ctm.Tags.With("endpoint", key.endpoint)
+		tags = tags.With("reply_code", strconv.Itoa(int(key.replyCode)))
+		tags = tags.With("routing_key", key.routingKey)
+		samples = append(samples, metrics.Sample{
+			Time: now,
+			TimeSeries: metrics.TimeSeries{
+				Metric: k9amqp.metrics.PublishReturned,
+				Tags:   tags,
 			},
-		},
+			Value:    float64(count),
+			Metadata: ctm.Metadata,
+		})
+	}
+	metrics.PushIfNotDone(k9amqp.vu.Context(), k9amqp.vu.State().Samples, metrics.ConnectedSamples{Samples: samples})
+}
+
+// watchConnEvents pushes pool connection events as they are raised, so events are reported while
+// VUs are blocked in publish. It's started once by the first call of the client made by a VU and
+// runs until the client is closed or the VU's scenario ends.
+func (client *Client) watchConnEvents() {
+	state := client.k9amqp.vu.State()
+	if state == nil {
+		return
+	}
+	client.connEvents.Do(func() {
+		ctx, samples, amqpClient := client.k9amqp.vu.Context(), state.Samples, client.amqpClient
+		ctm := state.Tags.GetCurrentValues()
+		go func() {
+			for {
+				select {
+				case <-amqpClient.done:
+					return
+				case <-ctx.Done():
+					return
+				case event := <-amqpClient.events:
+					metrics.PushIfNotDone(ctx, samples, client.k9amqp.connEventSample(event, ctm.Tags, ctm.Metadata))
+				}
+			}
+		}()
 	})
-	return nil
+}
+
+func (k9amqp *K9amqp) connEventSample(event connEvent, tags *metrics.TagSet, meta map[string]string) metrics.Sample {
+	var metric *metrics.Metric
+	switch event.kind {
+	case connLost:
+		metric = k9amqp.metrics.ConnLost
+	case connRecovered:
+		metric = k9amqp.metrics.ConnRecovered
+	case topologyRecovered:
+		metric = k9amqp.metrics.TopologyRecovered
+	case topologyRecoveryFailed:
+		metric = k9amqp.metrics.TopologyFailed
+	case connBlocked:
+		metric = k9amqp.metrics.ConnBlocked
+	case connBlockedTime:
+		metric = k9amqp.metrics.BlockedDuration
+	}
+	tags = tags.With("endpoint", event.endpoint.String())
+	if event.reason != "" {
+		tags = tags.With("reason", event.reason)
+	}
+	return metrics.Sample{
+		Time: event.time,
+		TimeSeries: metrics.TimeSeries{
+			Metric: metric,
+			Tags:   tags,
+		},
+		Value:    event.value,
+		Metadata: meta,
+	}
 }
 
 func randString(length int) string {
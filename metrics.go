@@ -13,6 +13,28 @@ type amqpMetrics struct {
 	ConsumeNoDelivery *metrics.Metric
 	ConsumeFailed     *metrics.Metric
 	ConsumeLatency    *metrics.Metric
+	ConnLost          *metrics.Metric
+	ConnRecovered     *metrics.Metric
+	TopologyRecovered *metrics.Metric
+	TopologyFailed    *metrics.Metric
+	PoolWait          *metrics.Metric
+	PoolExhausted     *metrics.Metric
+	Connections       *metrics.Metric
+	ChannelsIdle      *metrics.Metric
+	ChannelsOpened    *metrics.Metric
+	ChannelsErrClosed *metrics.Metric
+	ConnectDuration   *metrics.Metric
+	ConnBlocked       *metrics.Metric
+	BlockedDuration   *metrics.Metric
+	ConfirmLatency    *metrics.Metric
+	PublishNacked     *metrics.Metric
+	PublishReturned   *metrics.Metric
+	TxCommitLatency   *metrics.Metric
+	TxRollback        *metrics.Metric
+	E2ELatency        *metrics.Metric
+	MsgLost           *metrics.Metric
+	MsgDuplicated     *metrics.Metric
+	MsgOutOfOrder     *metrics.Metric
 }
 
 func registerMetrics(vu modules.VU) (amqpMetrics, error) {
@@ -27,7 +49,7 @@ func registerMetrics(vu modules.VU) (amqpMetrics, error) {
 	if err != nil {
 		return m, err
 	}
-	m.PublishLatency, err = registry.NewMetric("amqp_pub_latency", metrics.Trend)
+	m.PublishLatency, err = registry.NewMetric("amqp_pub_latency", metrics.Trend, metrics.Time)
 	if err != nil {
 		return m, err
 	}
@@ -43,7 +65,95 @@ func registerMetrics(vu modules.VU) (amqpMetrics, error) {
 	if err != nil {
 		return m, err
 	}
-	m.ConsumeLatency, err = registry.NewMetric("amqp_sub_latency", metrics.Trend)
+	m.ConsumeLatency, err = registry.NewMetric("amqp_sub_latency", metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+	m.ConnLost, err = registry.NewMetric("amqp_conn_lost", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.ConnRecovered, err = registry.NewMetric("amqp_conn_recovered", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.TopologyRecovered, err = registry.NewMetric("amqp_topology_recovered", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.TopologyFailed, err = registry.NewMetric("amqp_topology_recovery_failed", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.PoolWait, err = registry.NewMetric("amqp_pool_wait", metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+	m.PoolExhausted, err = registry.NewMetric("amqp_pool_exhausted", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.Connections, err = registry.NewMetric("amqp_connections", metrics.Gauge)
+	if err != nil {
+		return m, err
+	}
+	m.ChannelsIdle, err = registry.NewMetric("amqp_channels_idle", metrics.Gauge)
+	if err != nil {
+		return m, err
+	}
+	m.ChannelsOpened, err = registry.NewMetric("amqp_channels_opened", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.ChannelsErrClosed, err = registry.NewMetric("amqp_channels_closed_on_error", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.ConnectDuration, err = registry.NewMetric("amqp_connect_duration", metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+	m.ConnBlocked, err = registry.NewMetric("amqp_conn_blocked", metrics.Gauge)
+	if err != nil {
+		return m, err
+	}
+	m.BlockedDuration, err = registry.NewMetric("amqp_conn_blocked_duration", metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+	m.ConfirmLatency, err = registry.NewMetric("amqp_pub_confirm_latency", metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+	m.PublishNacked, err = registry.NewMetric("amqp_pub_nacked", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.PublishReturned, err = registry.NewMetric("amqp_pub_returned", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.TxCommitLatency, err = registry.NewMetric("amqp_tx_commit_latency", metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+	m.TxRollback, err = registry.NewMetric("amqp_tx_rollback", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.E2ELatency, err = registry.NewMetric("amqp_e2e_latency", metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+	m.MsgLost, err = registry.NewMetric("amqp_msg_lost", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.MsgDuplicated, err = registry.NewMetric("amqp_msg_duplicated", metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+	m.MsgOutOfOrder, err = registry.NewMetric("amqp_msg_out_of_order", metrics.Counter)
 	if err != nil {
 		return m, err
 	}
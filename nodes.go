@@ -0,0 +1,79 @@
+package k9amqp
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+const (
+	DistributionRoundRobin = "round_robin"
+	DistributionRandom     = "random"
+	DistributionWeighted   = "weighted"
+)
+
+// nodeSelector decides which cluster node a pooled connection is opened to.
+type nodeSelector struct {
+	distribution string
+	endpoints    []amqpEndpoint
+	sequence     []int
+}
+
+func newNodeSelector(distribution string, endpoints []amqpEndpoint) (*nodeSelector, error) {
+	selector := &nodeSelector{
+		distribution: distribution,
+		endpoints:    endpoints,
+	}
+	switch distribution {
+	case "", DistributionRoundRobin:
+		selector.distribution = DistributionRoundRobin
+	case DistributionRandom:
+	case DistributionWeighted:
+		selector.sequence = weightedSequence(endpoints)
+	default:
+		return nil, fmt.Errorf("unsupported pool distribution '%s'", distribution)
+	}
+	return selector, nil
+}
+
+// candidates returns endpoint indexes for idx-th connection, preferred node first, the rest as failover.
+func (s *nodeSelector) candidates(idx int) []int {
+	size := len(s.endpoints)
+	var preferred int
+	switch s.distribution {
+	case DistributionRandom:
+		// top level functions are safe for concurrent VUs and background recovery
+		preferred = rand.IntN(size)
+	case DistributionWeighted:
+		preferred = s.sequence[idx%len(s.sequence)]
+	default:
+		preferred = idx % size
+	}
+	candidates := make([]int, size)
+	for i := range size {
+		candidates[i] = (preferred + i) % size
+	}
+	return candidates
+}
+
+// weightedSequence spreads nodes by weight using smooth weighted round-robin,
+// e.g. weights 2, 1 produce sequence 0, 1, 0.
+func weightedSequence(endpoints []amqpEndpoint) []int {
+	total := 0
+	for _, endpoint := range endpoints {
+		total += endpoint.weight
+	}
+	current := make([]int, len(endpoints))
+	sequence := make([]int, 0, total)
+	for range total {
+		best := 0
+		for i, endpoint := range endpoints {
+			current[i] += endpoint.weight
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		sequence = append(sequence, best)
+	}
+	return sequence
+}
@@ -0,0 +1,87 @@
+package k9amqp
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func weightedEndpoints(weights ...int) []amqpEndpoint {
+	endpoints := make([]amqpEndpoint, len(weights))
+	for idx, weight := range weights {
+		endpoints[idx] = amqpEndpoint{weight: weight}
+	}
+	return endpoints
+}
+
+func TestWeightedSequence(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+		want    []int
+	}{
+		{"equal", []int{1, 1, 1}, []int{0, 1, 2}},
+		{"double", []int{2, 1}, []int{0, 1, 0}},
+		{"smooth", []int{5, 1, 1}, []int{0, 0, 1, 0, 2, 0, 0}},
+		{"single", []int{3}, []int{0, 0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := weightedSequence(weightedEndpoints(test.weights...)); !slices.Equal(got, test.want) {
+				t.Errorf("weightedSequence(%v) = %v, want %v", test.weights, got, test.want)
+			}
+		})
+	}
+}
+
+func TestWeightedSequenceShares(t *testing.T) {
+	weights := []int{3, 2, 5}
+	counts := make([]int, len(weights))
+	for _, idx := range weightedSequence(weightedEndpoints(weights...)) {
+		counts[idx]++
+	}
+	if !slices.Equal(counts, weights) {
+		t.Errorf("node shares %v, want %v", counts, weights)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	selector, err := newNodeSelector(DistributionWeighted, weightedEndpoints(2, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx, want := range [][]int{{0, 1}, {1, 0}, {0, 1}, {0, 1}} {
+		if got := selector.candidates(idx); !slices.Equal(got, want) {
+			t.Errorf("candidates(%d) = %v, want %v", idx, got, want)
+		}
+	}
+	selector, err = newNodeSelector("", weightedEndpoints(1, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := selector.candidates(4), []int{1, 2, 0}; !slices.Equal(got, want) {
+		t.Errorf("round robin candidates(4) = %v, want %v", got, want)
+	}
+	if _, err = newNodeSelector("sticky", weightedEndpoints(1)); err == nil {
+		t.Error("unsupported distribution accepted")
+	}
+}
+
+// TestCandidatesConcurrent is meaningful with -race, VUs and background recovery select nodes concurrently.
+func TestCandidatesConcurrent(t *testing.T) {
+	selector, err := newNodeSelector(DistributionRandom, weightedEndpoints(1, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for idx := range 8 {
+		wg.Go(func() {
+			for range 1000 {
+				if got := selector.candidates(idx); len(got) != 3 {
+					t.Errorf("candidates(%d) = %v, want all 3 nodes", idx, got)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,334 @@
+package k9amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errPoolExhausted = errors.New("amqp channel pool exhausted")
+
+// channelSeq numbers channels opened by the process, tracked messages carry the number.
+var channelSeq atomic.Uint64
+
+// AmqpPool shares channels of pooled connections. Connection slots are swapped atomically on recovery
+// and channels are opened without holding any lock, so concurrent callers open channels in parallel.
+type AmqpPool struct {
+	connections    []atomic.Pointer[amqpConnection]
+	pool           chan *amqpChannel
+	slots          chan struct{}
+	acquireTimeout time.Duration
+	maxAge         time.Duration
+	idleTimeout    time.Duration
+	nextId         atomic.Int64
+	connIdx        atomic.Uint64
+	stats          map[string]*endpointStats
+	lastSnapshot   atomic.Int64
+	open           func(*amqpConnection) (*amqp.Channel, error)
+	returned       func(amqpEndpoint, amqp.Return)
+}
+
+// amqpConnection is a pooled connection bound to the cluster node it was opened to.
+type amqpConnection struct {
+	*amqp.Connection
+	endpoint amqpEndpoint
+	block    blockState
+}
+
+// amqpChannel is a pooled channel, endpoint is the node of its connection.
+type amqpChannel struct {
+	*amqp.Channel
+	id            uint64
+	conn          *amqpConnection
+	endpoint      amqpEndpoint
+	pool          *AmqpPool
+	released      atomic.Bool
+	confirming    bool
+	transactional bool
+	confirms      *confirmTracker
+	returns       *returnWatch
+	created       time.Time
+	idleSince     time.Time
+}
+
+func (opt *PoolOptions) init() error {
+	if opt.ChannelsPerConn <= 0 {
+		opt.ChannelsPerConn = 2
+	}
+	if opt.ChannelsCacheSize < 0 {
+		opt.ChannelsCacheSize = 1
+	}
+	if opt.MaxChannels < 0 {
+		opt.MaxChannels = 0
+	}
+	if opt.WarmUp < 0 {
+		opt.WarmUp = 0
+	}
+	if opt.ConfirmWindow <= 0 {
+		opt.ConfirmWindow = 1000
+	}
+	if opt.Mode == "" {
+		opt.Mode = PoolModeShared
+	}
+	if err := validPoolMode(opt.Mode); err != nil {
+		return err
+	}
+	opt.acquireTimeout = 30 * time.Second
+	durations := []struct {
+		name  string
+		value string
+		into  *time.Duration
+	}{
+		{"acquire_timeout", opt.AcquireTimeout, &opt.acquireTimeout},
+		{"max_channel_age", opt.MaxChannelAge, &opt.maxChannelAge},
+		{"idle_timeout", opt.IdleTimeout, &opt.idleTimeout},
+		{"rotate_interval", opt.RotateInterval, &opt.rotateInterval},
+	}
+	for _, duration := range durations {
+		if duration.value == "" {
+			continue
+		}
+		var err error
+		if *duration.into, err = time.ParseDuration(duration.value); err != nil {
+			return fmt.Errorf("invalid %s: %w", duration.name, err)
+		}
+	}
+	return nil
+}
+
+func newAmqpPool(connections []*amqpConnection, poolOptions PoolOptions, endpoints []amqpEndpoint) *AmqpPool {
+	pool := &AmqpPool{
+		stats:          newPoolStats(endpoints),
+		connections:    make([]atomic.Pointer[amqpConnection], len(connections)),
+		pool:           make(chan *amqpChannel, poolOptions.ChannelsCacheSize),
+		acquireTimeout: poolOptions.acquireTimeout,
+		maxAge:         poolOptions.maxChannelAge,
+		idleTimeout:    poolOptions.idleTimeout,
+		open: func(conn *amqpConnection) (*amqp.Channel, error) {
+			return conn.Channel()
+		},
+	}
+	for idx, conn := range connections {
+		pool.connections[idx].Store(conn)
+	}
+	if poolOptions.MaxChannels > 0 {
+		pool.slots = make(chan struct{}, poolOptions.MaxChannels)
+	}
+	return pool
+}
+
+func (p *AmqpPool) bounded() bool {
+	return p.slots != nil
+}
+
+// get returns idle channel or opens a new one, when max_channels are open it waits
+// for a channel to be returned or closed until acquire_timeout.
+func (p *AmqpPool) get(ctx context.Context) (*amqpChannel, error) {
+	var timeout <-chan time.Time
+	for {
+		select {
+		case channel := <-p.pool:
+			p.endpointStats(channel.endpoint).idle.Add(-1)
+			if !p.usable(channel) {
+				continue
+			}
+			return channel, nil
+		default:
+		}
+		if !p.bounded() {
+			slog.Info("no available channel in pool, creating new one")
+			return p.channel()
+		}
+		select {
+		case p.slots <- struct{}{}:
+			slog.Info("no available channel in pool, creating new one")
+			return p.slotChannel()
+		default:
+		}
+		if timeout == nil {
+			timer := time.NewTimer(p.acquireTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case channel := <-p.pool:
+			p.endpointStats(channel.endpoint).idle.Add(-1)
+			if !p.usable(channel) {
+				continue
+			}
+			return channel, nil
+		case p.slots <- struct{}{}:
+			return p.slotChannel()
+		case <-timeout:
+			return nil, fmt.Errorf("%w, no channel available within %s", errPoolExhausted, p.acquireTimeout)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// slotChannel opens a channel for already acquired slot, the slot is freed on failure.
+func (p *AmqpPool) slotChannel() (*amqpChannel, error) {
+	channel, err := p.channel()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return channel, nil
+}
+
+func (p *AmqpPool) channel() (*amqpChannel, error) {
+	p.nextId.Add(1)
+	size := uint64(len(p.connections))
+	for range size {
+		conn := p.connections[p.connIdx.Add(1)%size].Load()
+		if conn.IsClosed() {
+			continue
+		}
+		channel, err := p.open(conn)
+		if err != nil {
+			return nil, err
+		}
+		p.endpointStats(conn.endpoint).opened.Add(1)
+		pooled := &amqpChannel{Channel: channel, id: channelSeq.Add(1), conn: conn, endpoint: conn.endpoint, pool: p}
+		if p.maxAge > 0 {
+			pooled.created = time.Now()
+		}
+		if p.returned != nil {
+			pooled.watchReturns(p.returned)
+		}
+		return pooled, nil
+	}
+	return nil, errors.New("no open amqp connection in pool")
+}
+
+// replace swaps idx-th connection when it's still the old one, connection replaced by rotation or
+// recovery meanwhile is kept.
+func (p *AmqpPool) replace(idx int, old, conn *amqpConnection) bool {
+	return p.connections[idx].CompareAndSwap(old, conn)
+}
+
+// conns returns current pool connections.
+func (p *AmqpPool) conns() []*amqpConnection {
+	conns := make([]*amqpConnection, len(p.connections))
+	for idx := range p.connections {
+		conns[idx] = p.connections[idx].Load()
+	}
+	return conns
+}
+
+func (p *AmqpPool) put(channel *amqpChannel, amqpError error) error {
+	if channel.IsClosed() {
+		slog.Warn("blow channel after error")
+		p.endpointStats(channel.endpoint).closedOnError.Add(1)
+		p.release(channel)
+		return nil
+	}
+	if p.idleTimeout > 0 {
+		channel.idleSince = time.Now()
+	}
+	stats := p.endpointStats(channel.endpoint)
+	stats.idle.Add(1)
+	select {
+	case p.pool <- channel:
+		return nil
+	default:
+		stats.idle.Add(-1)
+		slog.Info("pool is full, closing channel")
+		return channel.Close()
+	}
+}
+
+// usable checks idle channel taken from the pool, closed and expired channels are dropped.
+func (p *AmqpPool) usable(channel *amqpChannel) bool {
+	if channel.IsClosed() {
+		slog.Info("channel from pool is closed, dropping it")
+		p.endpointStats(channel.endpoint).closedOnError.Add(1)
+		p.release(channel)
+		return false
+	}
+	if p.expires() && p.expired(channel, time.Now()) {
+		slog.Info("channel from pool expired, closing it")
+		if err := channel.Close(); err != nil {
+			slog.Error("failed to close expired channel", "error", err)
+		}
+		return false
+	}
+	return true
+}
+
+func (p *AmqpPool) expires() bool {
+	return p.maxAge > 0 || p.idleTimeout > 0
+}
+
+func (p *AmqpPool) expired(channel *amqpChannel, now time.Time) bool {
+	return (p.maxAge > 0 && now.Sub(channel.created) > p.maxAge) ||
+		(p.idleTimeout > 0 && now.Sub(channel.idleSince) > p.idleTimeout)
+}
+
+// evictExpired closes idle channels exceeding max_channel_age or idle_timeout.
+func (p *AmqpPool) evictExpired() {
+	for range len(p.pool) {
+		select {
+		case channel := <-p.pool:
+			stats := p.endpointStats(channel.endpoint)
+			stats.idle.Add(-1)
+			if !p.usable(channel) {
+				continue
+			}
+			stats.idle.Add(1)
+			select {
+			case p.pool <- channel:
+			default:
+				stats.idle.Add(-1)
+				if err := channel.Close(); err != nil {
+					slog.Error("failed to close channel", "error", err)
+				}
+			}
+		default:
+			return
+		}
+	}
+}
+
+// warmUp opens channels into the pool up to the pool size and max_channels.
+func (p *AmqpPool) warmUp(count int) error {
+	for range min(count, cap(p.pool)) {
+		if p.bounded() {
+			select {
+			case p.slots <- struct{}{}:
+			default:
+				return nil
+			}
+		}
+		channel, err := p.channel()
+		if err != nil {
+			if p.bounded() {
+				<-p.slots
+			}
+			return err
+		}
+		if err := p.put(channel, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// release frees the channel's slot, it's safe to be called more than once for the same channel.
+func (p *AmqpPool) release(channel *amqpChannel) {
+	if p.bounded() && channel.released.CompareAndSwap(false, true) {
+		<-p.slots
+	}
+}
+
+// Close closes the channel and frees its pool slot.
+func (channel *amqpChannel) Close() error {
+	channel.pool.release(channel)
+	return channel.Channel.Close()
+}
@@ -0,0 +1,72 @@
+package k9amqp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// channelOpenLatency simulates channel.open round-trip to the broker.
+const channelOpenLatency = 200 * time.Microsecond
+
+func benchmarkPool(tb testing.TB, poolOptions PoolOptions) *AmqpPool {
+	tb.Helper()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := poolOptions.init(); err != nil {
+		tb.Fatal(err)
+	}
+	connections := make([]*amqpConnection, 4)
+	for idx := range connections {
+		connections[idx] = &amqpConnection{Connection: &amqp.Connection{}}
+	}
+	pool := newAmqpPool(connections, poolOptions, []amqpEndpoint{{}})
+	pool.open = func(*amqpConnection) (*amqp.Channel, error) {
+		time.Sleep(channelOpenLatency)
+		return &amqp.Channel{}, nil
+	}
+	return pool
+}
+
+// BenchmarkPoolOpenChannel acquires a new channel every time, channel opening
+// must not serialise concurrent callers.
+func BenchmarkPoolOpenChannel(b *testing.B) {
+	pool := benchmarkPool(b, PoolOptions{})
+	ctx := context.Background()
+	b.SetParallelism(64)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			channel, err := pool.get(ctx)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			pool.release(channel)
+		}
+	})
+}
+
+// BenchmarkPoolIdleChannel acquires and returns channels cached by the pool.
+func BenchmarkPoolIdleChannel(b *testing.B) {
+	pool := benchmarkPool(b, PoolOptions{ChannelsCacheSize: 1024, MaxChannels: 256})
+	ctx := context.Background()
+	b.SetParallelism(64)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			channel, err := pool.get(ctx)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			if err := pool.put(channel, nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,397 @@
+package k9amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.k6.io/k6/v2/metrics"
+)
+
+const (
+	ProfileConstant = "constant"
+	ProfileRamp     = "ramp"
+	ProfileStep     = "step"
+	ProfileSine     = "sine"
+	ProfileBurst    = "burst"
+
+	// producerTick bounds producer sleep, so rate changes of the profile are followed.
+	producerTick = 100 * time.Millisecond
+	// producerFlush bounds buffered metric samples of a producer.
+	producerFlush = 1000
+)
+
+var errProducerInit = errors.New("producer can be started by VU only, not in init context")
+
+// Producer publishes messages in background at rate of its profile, until its duration elapses,
+// it's stopped or the VU's scenario ends.
+type Producer struct {
+	client  *Client
+	opts    ProducerOptions
+	msg     amqp.Publishing
+	channel *amqpChannel
+	conn    *amqpConnection
+	pool    *AmqpPool
+	scope   string
+	cancel  context.CancelFunc
+	done    chan struct{}
+	sent    atomic.Int64
+	failed  atomic.Int64
+	report  producerReporter
+}
+
+// producerReporter buffers publish metrics of a producer, samples are pushed in chunks.
+type producerReporter struct {
+	k9amqp  *K9amqp
+	ctx     context.Context
+	state   chan<- metrics.SampleContainer
+	tags    *metrics.TagSet
+	meta    map[string]string
+	confirm func(time.Duration, bool)
+	samples []metrics.Sample
+	flushed time.Time
+}
+
+// StartProducer starts background publisher of the message, body generator of the options fills
+// empty message body. VU id and iteration rendered by templates are the ones the producer was started at.
+func (client *Client) StartProducer(opts ProducerOptions, msg amqp.Publishing) (*Producer, error) {
+	state := client.k9amqp.vu.State()
+	if state == nil {
+		return nil, errProducerInit
+	}
+	if err := opts.init(); err != nil {
+		return nil, err
+	}
+	if opts.Track {
+		opts.source = tracker.newSource()
+	}
+	opts.origin = &vuOrigin{vu: state.VUID, iteration: state.Iteration}
+	ctx, cancel := context.WithCancel(client.context())
+	producer := &Producer{client: client, opts: opts, msg: msg, scope: client.connScope(), cancel: cancel, done: make(chan struct{})}
+	ctm := state.Tags.GetCurrentValues()
+	producer.report = producerReporter{k9amqp: &client.k9amqp, ctx: client.context(), state: state.Samples, tags: ctm.Tags, meta: ctm.Metadata}
+	slog.Debug("producer started", "exchange", opts.Exchange, "routing_key", opts.Key, "profile", opts.Profile)
+	go producer.run(ctx)
+	return producer, nil
+}
+
+// Stop stops the producer and waits for its last publish.
+func (producer *Producer) Stop() {
+	producer.cancel()
+	<-producer.done
+}
+
+// Wait waits until the producer is done, so the VU keeps its scenario running.
+func (producer *Producer) Wait() {
+	<-producer.done
+}
+
+func (producer *Producer) Running() bool {
+	select {
+	case <-producer.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (producer *Producer) Sent() int64 {
+	return producer.sent.Load()
+}
+
+func (producer *Producer) Failed() int64 {
+	return producer.failed.Load()
+}
+
+// run publishes messages the profile rate allows since the previous publish, backlog of a stalled
+// producer is bounded to a second of the rate.
+func (producer *Producer) run(ctx context.Context) {
+	defer close(producer.done)
+	defer producer.cancel()
+	defer producer.release()
+	defer producer.report.flush()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	startTime := time.Now()
+	last := startTime
+	var credit float64
+	for ctx.Err() == nil {
+		now := time.Now()
+		elapsed := now.Sub(startTime)
+		if producer.opts.duration > 0 && elapsed >= producer.opts.duration {
+			break
+		}
+		rate := producer.opts.rate(elapsed)
+		credit = min(credit+rate*now.Sub(last).Seconds(), max(rate, 1))
+		last = now
+		if credit >= 1 {
+			credit--
+			producer.publish(ctx)
+			continue
+		}
+		wait := producerTick
+		if rate > 0 {
+			wait = min(time.Duration((1-credit)/rate*float64(time.Second)), producerTick)
+		}
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+	}
+	slog.Debug("producer stopped", "exchange", producer.opts.Exchange, "routing_key", producer.opts.Key,
+		"sent", producer.sent.Load(), "failed", producer.failed.Load())
+}
+
+func (producer *Producer) publish(ctx context.Context) {
+	if producer.channel == nil {
+		if err := producer.acquire(ctx); err != nil {
+			producer.failed.Add(1)
+			slog.Debug("producer failed to get amqp channel", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(producerTick):
+			}
+			return
+		}
+	}
+	client, channel, opts := producer.client, producer.channel, producer.opts.PublishOptions
+	startTime := time.Now()
+	async := client.amqpClient.poolOptions.AsyncConfirm
+	confirm := opts.Confirm || client.amqpClient.poolOptions.Confirm || async
+	var err error
+	if confirm {
+		err = channel.confirmMode()
+	}
+	var duration, confirmLatency time.Duration
+	var confirmation *amqp.DeferredConfirmation
+	var acked bool
+	switch {
+	case err != nil:
+	case async:
+		tracker := channel.tracker(client.amqpClient.poolOptions.ConfirmWindow)
+		if err = tracker.reserve(ctx, opts.timeout); err != nil {
+			break
+		}
+		duration, confirmation, err = client.publish(channel, opts, producer.msg)
+		if err != nil {
+			tracker.release()
+			break
+		}
+		go tracker.await(confirmation, startTime, producer.report.confirm)
+	default:
+		duration, confirmation, err = client.publish(channel, opts, producer.msg)
+		if err == nil && confirm {
+			acked, err = client.waitConfirm(confirmation, opts)
+			confirmLatency = time.Since(startTime)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		producer.failed.Add(1)
+		slog.Debug("producer failed to publish", "error", err)
+		producer.discard(err)
+	} else {
+		producer.sent.Add(1)
+	}
+	producer.report.add(AmqpProduceResponse{Error: err != nil, Confirmed: confirm && !async && err == nil, Acked: acked}, duration, confirmLatency)
+}
+
+// acquire gets channel the producer publishes on until the channel fails.
+func (producer *Producer) acquire(ctx context.Context) error {
+	channels := producer.client.amqpClient.channels
+	if producer.client.amqpClient.dedicated() {
+		var err error
+		if channels, err = producer.connect(); err != nil {
+			return err
+		}
+	}
+	channel, err := channels.get(ctx)
+	if err != nil {
+		return err
+	}
+	producer.channel = channel
+	producer.report.endpoint(channel.endpoint, producer.opts.PublishOptions)
+	return nil
+}
+
+// connect returns channels of the producer's own connection in per_vu and per_iteration modes, so
+// the producer doesn't depend on connection of the VU closed at iteration end. The connection is
+// reopened when it's closed.
+func (producer *Producer) connect() (*AmqpPool, error) {
+	if producer.conn != nil && !producer.conn.IsClosed() {
+		return producer.pool, nil
+	}
+	producer.close()
+	amqpClient := producer.client.amqpClient
+	startTime := time.Now()
+	conn, err := amqpClient.connectNode(amqpClient.selector.candidates(int(producer.opts.origin.vu)), make(map[int]bool), producer.scope)
+	if err != nil {
+		return nil, err
+	}
+	producer.report.connect(conn.endpoint, time.Since(startTime))
+	slog.Debug("producer connection opened", "vu", producer.opts.origin.vu, "endpoint", conn.endpoint.String())
+	producer.conn = conn
+	producer.pool = newAmqpPool([]*amqpConnection{conn}, PoolOptions{ChannelsCacheSize: 1}, amqpClient.amqpOptions.endpoints)
+	producer.pool.returned = amqpClient.returned
+	return producer.pool, nil
+}
+
+// close closes own connection of the producer together with its channels.
+func (producer *Producer) close() {
+	if producer.conn == nil {
+		return
+	}
+	if err := producer.conn.Close(); err != nil {
+		slog.Debug("failed to close producer connection", "error", err)
+	}
+	producer.conn, producer.pool = nil, nil
+}
+
+// discard drops the channel the publish failed on, same as Client.publish does.
+func (producer *Producer) discard(err error) {
+	switch {
+	case errors.Is(err, errBlocked) || errors.Is(err, errConfirmWindowFull) || errors.Is(err, errTemplate):
+	case errors.Is(err, errPublishTimeout):
+		producer.channel = nil
+	default:
+		if closeErr := producer.channel.blow(); closeErr != nil {
+			slog.Debug("failed to close producer channel after error", "error", closeErr)
+		}
+		producer.channel = nil
+	}
+}
+
+func (producer *Producer) release() {
+	defer producer.close()
+	if producer.channel == nil {
+		return
+	}
+	if putErr := producer.channel.pool.put(producer.channel, nil); putErr != nil {
+		slog.Error("failed to return producer channel to pool", "error", putErr)
+	}
+	producer.channel = nil
+}
+
+func (report *producerReporter) endpoint(endpoint amqpEndpoint, opts PublishOptions) {
+	report.flush()
+	report.tags = report.tags.With("endpoint", endpoint.String())
+	report.tags = report.tags.With("exchange", opts.Exchange)
+	report.tags = report.tags.With("routing_key", opts.Key)
+	tags := report.tags
+	report.confirm = func(latency time.Duration, acked bool) {
+		samples := report.k9amqp.confirmSamples(time.Now(), tags, report.meta, latency, acked)
+		metrics.PushIfNotDone(report.ctx, report.state, metrics.ConnectedSamples{Samples: samples})
+	}
+}
+
+// connect pushes duration of opening the producer's connection.
+func (report *producerReporter) connect(endpoint amqpEndpoint, duration time.Duration) {
+	metrics.PushIfNotDone(report.ctx, report.state, metrics.Sample{
+		Time: time.Now(),
+		TimeSeries: metrics.TimeSeries{
+			Metric: report.k9amqp.metrics.ConnectDuration,
+			Tags:   report.tags.With("endpoint", endpoint.String()),
+		},
+		Value:    metrics.D(duration),
+		Metadata: report.meta,
+	})
+}
+
+func (report *producerReporter) add(resp AmqpProduceResponse, duration, confirmLatency time.Duration) {
+	now := time.Now()
+	report.samples = append(report.samples, report.k9amqp.publishSamples(now, report.tags, report.meta, resp, duration, confirmLatency)...)
+	if len(report.samples) >= producerFlush || now.Sub(report.flushed) >= producerTick {
+		report.flush()
+	}
+}
+
+func (report *producerReporter) flush() {
+	report.flushed = time.Now()
+	if len(report.samples) == 0 {
+		return
+	}
+	metrics.PushIfNotDone(report.ctx, report.state, metrics.Samples(report.samples))
+	report.samples = make([]metrics.Sample, 0, producerFlush)
+}
+
+func (opts *ProducerOptions) init() error {
+	if err := opts.PublishOptions.init(); err != nil {
+		return err
+	}
+	var err error
+	for _, duration := range []struct {
+		name  string
+		text  string
+		value *time.Duration
+	}{
+		{"duration", opts.Duration, &opts.duration},
+		{"period", opts.Period, &opts.period},
+		{"burst_duration", opts.BurstDuration, &opts.burstDuration},
+	} {
+		if duration.text == "" {
+			continue
+		}
+		if *duration.value, err = time.ParseDuration(duration.text); err != nil {
+			return fmt.Errorf("invalid producer %s: %w", duration.name, err)
+		}
+	}
+	if opts.Rate < 0 || opts.TargetRate < 0 || opts.BurstRate < 0 {
+		return errors.New("producer rate must not be negative")
+	}
+	switch opts.Profile {
+	case "":
+		opts.Profile = ProfileConstant
+	case ProfileConstant:
+	case ProfileRamp:
+		if opts.duration <= 0 {
+			return errors.New("ramp producer profile requires duration")
+		}
+	case ProfileStep, ProfileSine:
+		if opts.period <= 0 {
+			return fmt.Errorf("%s producer profile requires period", opts.Profile)
+		}
+	case ProfileBurst:
+		if opts.period <= 0 || opts.burstDuration <= 0 || opts.burstDuration > opts.period {
+			return errors.New("burst producer profile requires burst_duration within period")
+		}
+	default:
+		return fmt.Errorf("unsupported producer profile '%s'", opts.Profile)
+	}
+	return nil
+}
+
+// rate returns messages per second of the profile at the elapsed time.
+func (opts *ProducerOptions) rate(elapsed time.Duration) float64 {
+	switch opts.Profile {
+	case ProfileRamp:
+		progress := min(float64(elapsed)/float64(opts.duration), 1)
+		return opts.Rate + (opts.TargetRate-opts.Rate)*progress
+	case ProfileStep:
+		rate := opts.Rate + opts.Step*float64(elapsed/opts.period)
+		if opts.TargetRate > 0 {
+			if opts.Step >= 0 {
+				rate = min(rate, opts.TargetRate)
+			} else {
+				rate = max(rate, opts.TargetRate)
+			}
+		}
+		return max(rate, 0)
+	case ProfileSine:
+		return max(opts.Rate+opts.Amplitude*math.Sin(2*math.Pi*float64(elapsed)/float64(opts.period)), 0)
+	case ProfileBurst:
+		if elapsed%opts.period < opts.burstDuration {
+			return opts.BurstRate
+		}
+		return opts.Rate
+	default:
+		return opts.Rate
+	}
+}
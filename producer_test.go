@@ -0,0 +1,69 @@
+package k9amqp
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProducerRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ProducerOptions
+		elapsed time.Duration
+		want    float64
+	}{
+		{"constant", ProducerOptions{Rate: 100}, time.Hour, 100},
+		{"ramp start", ProducerOptions{Profile: ProfileRamp, Rate: 100, TargetRate: 300, duration: time.Minute}, 0, 100},
+		{"ramp half", ProducerOptions{Profile: ProfileRamp, Rate: 100, TargetRate: 300, duration: time.Minute}, 30 * time.Second, 200},
+		{"ramp end", ProducerOptions{Profile: ProfileRamp, Rate: 100, TargetRate: 300, duration: time.Minute}, time.Hour, 300},
+		{"step", ProducerOptions{Profile: ProfileStep, Rate: 10, Step: 5, period: time.Second}, 2500 * time.Millisecond, 20},
+		{"step target", ProducerOptions{Profile: ProfileStep, Rate: 10, Step: 5, TargetRate: 18, period: time.Second}, 10 * time.Second, 18},
+		{"step down target", ProducerOptions{Profile: ProfileStep, Rate: 50, Step: -10, TargetRate: 25, period: time.Second}, 10 * time.Second, 25},
+		{"step down", ProducerOptions{Profile: ProfileStep, Rate: 10, Step: -5, period: time.Second}, 10 * time.Second, 0},
+		{"sine peak", ProducerOptions{Profile: ProfileSine, Rate: 100, Amplitude: 50, period: 4 * time.Second}, time.Second, 150},
+		{"sine trough", ProducerOptions{Profile: ProfileSine, Rate: 100, Amplitude: 50, period: 4 * time.Second}, 3 * time.Second, 50},
+		{"sine negative", ProducerOptions{Profile: ProfileSine, Rate: 10, Amplitude: 50, period: 4 * time.Second}, 3 * time.Second, 0},
+		{"burst", ProducerOptions{Profile: ProfileBurst, Rate: 10, BurstRate: 1000, period: 10 * time.Second, burstDuration: time.Second}, 20500 * time.Millisecond, 1000},
+		{"burst idle", ProducerOptions{Profile: ProfileBurst, Rate: 10, BurstRate: 1000, period: 10 * time.Second, burstDuration: time.Second}, 25 * time.Second, 10},
+	}
+	for _, test := range tests {
+		if got := test.opts.rate(test.elapsed); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: rate at %s = %f, want %f", test.name, test.elapsed, got, test.want)
+		}
+	}
+}
+
+func TestProducerOptionsInit(t *testing.T) {
+	opts := ProducerOptions{Profile: ProfileBurst, Period: "10s", BurstDuration: "1s", Duration: "1m"}
+	if err := opts.init(); err != nil {
+		t.Fatal(err)
+	}
+	if opts.period != 10*time.Second || opts.burstDuration != time.Second || opts.duration != time.Minute {
+		t.Errorf("parsed period %s, burst_duration %s, duration %s", opts.period, opts.burstDuration, opts.duration)
+	}
+	opts = ProducerOptions{Rate: 1}
+	if err := opts.init(); err != nil || opts.Profile != ProfileConstant {
+		t.Errorf("default profile %q, %v, want %q", opts.Profile, err, ProfileConstant)
+	}
+}
+
+func TestProducerOptionsInitInvalid(t *testing.T) {
+	for name, opts := range map[string]ProducerOptions{
+		"ramp duration":  {Profile: ProfileRamp, TargetRate: 10},
+		"step period":    {Profile: ProfileStep, Step: 1},
+		"sine period":    {Profile: ProfileSine, Amplitude: 1},
+		"burst duration": {Profile: ProfileBurst, Period: "1s"},
+		"burst period":   {Profile: ProfileBurst, Period: "1s", BurstDuration: "2s"},
+		"profile":        {Profile: "square"},
+		"rate":           {Rate: -1},
+		"target rate":    {Profile: ProfileRamp, TargetRate: -1, Duration: "1m"},
+		"duration":       {Duration: "soon"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := opts.init(); err == nil {
+				t.Errorf("options %+v accepted", opts)
+			}
+		})
+	}
+}
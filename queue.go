@@ -13,19 +13,19 @@ func (queue *Queue) Declare(client *Client, opts QueueDeclareOptions) (*amqp.Que
 	if client == nil {
 		return nil, errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return nil, err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
  				slog.Error("failed to close channel after error", "error", closeErr)
  			}
 		}
@@ -52,6 +52,7 @@ func (queue *Queue) Declare(client *Client, opts QueueDeclareOptions) (*amqp.Que
 	if err != nil {
 		return nil, err
 	}
+	client.amqpClient.topology.declareQueue(opts)
 	slog.Info("queue created", "name", amqpQueue.Name)
 	return &amqpQueue, nil
 }
@@ -61,19 +62,19 @@ func (queue *Queue) Delete(client *Client, opts QueueDeleteOptions) error {
 	if client == nil {
 		return errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
  				slog.Error("failed to close channel after error", "error", closeErr)
  			}
 		}
@@ -87,6 +88,7 @@ func (queue *Queue) Delete(client *Client, opts QueueDeleteOptions) error {
 	if err != nil {
 		return err
 	}
+	client.amqpClient.topology.deleteQueue(opts.Name)
 	slog.Info("queue deleted", "name", opts.Name)
 	return nil
 }
@@ -95,19 +97,19 @@ func (queue *Queue) Bind(client *Client, opts QueueBindOptions) error {
 	if client == nil {
 		return errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
  				slog.Error("failed to close channel after error", "error", closeErr)
  			}
 		}
@@ -119,6 +121,9 @@ func (queue *Queue) Bind(client *Client, opts QueueBindOptions) error {
 		opts.NoWait,
 		opts.Args,
 	)
+	if err == nil {
+		client.amqpClient.topology.bindQueue(opts)
+	}
 	slog.Info("queue binded", "name", opts.Name, "key", opts.Key)
 	return err
 }
@@ -127,19 +132,19 @@ func (queue *Queue) Unbind(client *Client, opts QueueUnbindOptions) error {
 	if client == nil {
 		return errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
  				slog.Error("failed to close channel after error", "error", closeErr)
  			}
 		}
@@ -150,6 +155,9 @@ func (queue *Queue) Unbind(client *Client, opts QueueUnbindOptions) error {
 		opts.Exchange,
 		opts.Args,
 	)
+	if err == nil {
+		client.amqpClient.topology.unbindQueue(opts.Name, opts.Key, opts.Exchange)
+	}
 	slog.Info("queue unbinded", "name", opts.Name, "key", opts.Key)
 	return err
 }
@@ -158,19 +166,19 @@ func (queue *Queue) Purge(client *Client, opts QueuePurgeOptions) (int, error) {
 	if client == nil {
 		return 0, errors.New("required 'client' parameter missing")
 	}
-	channel, err := client.amqpClient.channels.get()
+	channel, err := client.acquire()
 	if err != nil {
 		slog.Error("unable to get amqp channel")
 		return 0, err
 	}
 	defer func() {
 		if err == nil {
-			if putErr := client.amqpClient.channels.put(channel, err); putErr != nil {
+			if putErr := channel.pool.put(channel, err); putErr != nil {
 				slog.Error("failed to return channel to pool", "error", putErr)
 			}
 		} else {
 			slog.Info("blows channel after error")
-			if closeErr := channel.Close(); closeErr != nil {
+			if closeErr := channel.blow(); closeErr != nil {
  				slog.Error("failed to close channel after error", "error", closeErr)
  			}
 		}
@@ -0,0 +1,135 @@
+package k9amqp
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type connEventKind int
+
+const (
+	connLost connEventKind = iota
+	connRecovered
+	topologyRecovered
+	topologyRecoveryFailed
+	connBlocked
+	connBlockedTime
+)
+
+// connEvent is a pool event raised outside of VU context, reported as metric by the next VU call.
+type connEvent struct {
+	kind     connEventKind
+	endpoint amqpEndpoint
+	time     time.Time
+	value    float64
+	reason   string
+}
+
+func (opt *RecoveryOptions) init() error {
+	var err error
+	opt.initialInterval = 500 * time.Millisecond
+	if opt.InitialInterval != "" {
+		if opt.initialInterval, err = time.ParseDuration(opt.InitialInterval); err != nil {
+			return fmt.Errorf("invalid recovery initial_interval: %w", err)
+		}
+	}
+	opt.maxInterval = 30 * time.Second
+	if opt.MaxInterval != "" {
+		if opt.maxInterval, err = time.ParseDuration(opt.MaxInterval); err != nil {
+			return fmt.Errorf("invalid recovery max_interval: %w", err)
+		}
+	}
+	if opt.Multiplier < 1 {
+		opt.Multiplier = 2
+	}
+	return nil
+}
+
+func (amqpClient *AmqpClient) event(kind connEventKind, endpoint amqpEndpoint, value float64) {
+	amqpClient.send(connEvent{kind: kind, endpoint: endpoint, time: time.Now(), value: value})
+}
+
+func (amqpClient *AmqpClient) send(event connEvent) {
+	select {
+	case amqpClient.events <- event:
+	default:
+		slog.Warn("connection events buffer is full, dropping event", "endpoint", event.endpoint.String())
+	}
+}
+
+// watch reports loss of idx-th pool connection once it's closed by an error and recovers it,
+// unless recovery is disabled.
+func (amqpClient *AmqpClient) watch(idx int, conn *amqpConnection) {
+	notify := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		amqpErr := <-notify
+		if amqpErr == nil || amqpClient.isClosed() {
+			return
+		}
+		slog.Warn("pool connection lost", "connection", idx, "endpoint", conn.endpoint.String(), "error", amqpErr)
+		amqpClient.event(connLost, conn.endpoint, 1)
+		if amqpClient.poolOptions.Recovery.Disabled || amqpClient.channels.connections[idx].Load() != conn {
+			// recovery disabled, or rotated out connection closed during grace period
+			return
+		}
+		amqpClient.recover(idx, conn)
+	}()
+}
+
+// recover replaces the lost idx-th pool connection, unless it was replaced meanwhile.
+func (amqpClient *AmqpClient) recover(idx int, lost *amqpConnection) {
+	recovery := amqpClient.poolOptions.Recovery
+	interval := recovery.initialInterval
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-amqpClient.done:
+			return
+		case <-time.After(interval):
+		}
+		conn, err := amqpClient.connectNode(amqpClient.selector.candidates(idx), make(map[int]bool), amqpClient.scope)
+		if err == nil && (amqpClient.isClosed() || !amqpClient.channels.replace(idx, lost, conn)) {
+			slog.Debug("recovered connection is not needed", "connection", idx)
+			if closeErr := conn.Close(); closeErr != nil {
+				slog.Error("failed to close connection", "error", closeErr)
+			}
+			return
+		}
+		if err == nil {
+			slog.Info("pool connection recovered", "connection", idx, "endpoint", conn.endpoint.String(), "attempt", attempt)
+			amqpClient.event(connRecovered, conn.endpoint, 1)
+			if recovery.Topology {
+				amqpClient.recoverTopology(conn)
+			}
+			amqpClient.watch(idx, conn)
+			return
+		}
+		if recovery.MaxAttempts > 0 && attempt >= recovery.MaxAttempts {
+			slog.Error("pool connection recovery failed, giving up", "connection", idx, "attempts", attempt, "error", err)
+			return
+		}
+		interval = min(time.Duration(float64(interval)*recovery.Multiplier), recovery.maxInterval)
+	}
+}
+
+func (amqpClient *AmqpClient) isClosed() bool {
+	select {
+	case <-amqpClient.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (amqpClient *AmqpClient) recoverTopology(conn *amqpConnection) {
+	recovered, failed := amqpClient.topology.replay(conn.Connection)
+	if failed > 0 {
+		slog.Warn("topology recovered with failures", "endpoint", conn.endpoint.String(), "recovered", recovered, "failed", failed)
+	} else {
+		slog.Info("topology recovered", "endpoint", conn.endpoint.String(), "recovered", recovered)
+	}
+	amqpClient.event(topologyRecovered, conn.endpoint, float64(recovered))
+	amqpClient.event(topologyRecoveryFailed, conn.endpoint, float64(failed))
+}
@@ -0,0 +1,151 @@
+package k9amqp
+
+import (
+	"log/slog"
+	"maps"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// returnKey groups returned messages reported by amqp_pub_returned counter.
+type returnKey struct {
+	endpoint   string
+	replyCode  uint16
+	routingKey string
+}
+
+// returnStats counts messages returned by broker since the last report.
+type returnStats struct {
+	mutex  sync.Mutex
+	counts map[returnKey]int64
+}
+
+func (stats *returnStats) add(endpoint amqpEndpoint, ret amqp.Return) {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	if stats.counts == nil {
+		stats.counts = make(map[returnKey]int64)
+	}
+	stats.counts[returnKey{endpoint: endpoint.String(), replyCode: ret.ReplyCode, routingKey: ret.RoutingKey}]++
+}
+
+func (stats *returnStats) drain() map[returnKey]int64 {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	counts := stats.counts
+	stats.counts = nil
+	return counts
+}
+
+func (amqpClient *AmqpClient) returned(endpoint amqpEndpoint, ret amqp.Return) {
+	slog.Debug("message returned by broker", "endpoint", endpoint.String(), "exchange", ret.Exchange, "routing_key", ret.RoutingKey, "reply_code", ret.ReplyCode, "reply_text", ret.ReplyText)
+	amqpClient.returns.add(endpoint, ret)
+}
+
+// publishIDHeader holds channel scoped id of a mandatory publish waiting for confirm, so its return is
+// told apart from returns of other publishes.
+const publishIDHeader = "x-k9amqp-publish-id"
+
+// returnWatch follows messages returned on a channel. Returns are taken by a single goroutine,
+// publisher matches them to awaited publish ids once the goroutine handled all taken returns.
+type returnWatch struct {
+	mutex   sync.Mutex
+	seq     uint64
+	awaited map[uint64]bool
+	sync    chan chan struct{}
+	done    chan struct{}
+}
+
+// watchReturns follows messages returned on the channel until it's closed.
+func (channel *amqpChannel) watchReturns(returned func(amqpEndpoint, amqp.Return)) {
+	channel.returns = newReturnWatch()
+	returns := channel.NotifyReturn(make(chan amqp.Return))
+	go channel.returns.run(returns, func(ret amqp.Return) {
+		returned(channel.endpoint, ret)
+	})
+}
+
+func newReturnWatch() *returnWatch {
+	return &returnWatch{awaited: make(map[uint64]bool), sync: make(chan chan struct{}), done: make(chan struct{})}
+}
+
+func (watch *returnWatch) run(returns <-chan amqp.Return, returned func(amqp.Return)) {
+	defer close(watch.done)
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				return
+			}
+			watch.returned(ret)
+			returned(ret)
+		case handled := <-watch.sync:
+			close(handled)
+		}
+	}
+}
+
+// awaitReturn stamps the message with id its return is matched by, ok is false when returns of
+// the channel are not watched.
+func (channel *amqpChannel) awaitReturn(msg amqp.Publishing) (amqp.Publishing, uint64, bool) {
+	if channel.returns == nil {
+		return msg, 0, false
+	}
+	var id uint64
+	msg.Headers, id = channel.returns.await(msg.Headers)
+	return msg, id, true
+}
+
+// await returns copy of the headers with id of the publish, the return of which is awaited.
+func (watch *returnWatch) await(headers amqp.Table) (amqp.Table, uint64) {
+	watch.mutex.Lock()
+	defer watch.mutex.Unlock()
+	watch.seq++
+	watch.awaited[watch.seq] = false
+	stamped := make(amqp.Table, len(headers)+1)
+	maps.Copy(stamped, headers)
+	stamped[publishIDHeader] = int64(watch.seq) //nolint:gosec // ids don't overflow int64
+	return stamped, watch.seq
+}
+
+func (watch *returnWatch) returned(ret amqp.Return) {
+	id, ok := ret.Headers[publishIDHeader].(int64)
+	if !ok {
+		return
+	}
+	watch.mutex.Lock()
+	defer watch.mutex.Unlock()
+	if _, awaited := watch.awaited[uint64(id)]; awaited {
+		watch.awaited[uint64(id)] = true
+	}
+}
+
+// wasReturned tells whether the confirmed publish was returned. Broker sends the return before
+// the confirm and the listener is unbuffered, so the return was taken before the confirm arrived,
+// only its handling has to be awaited.
+func (watch *returnWatch) wasReturned(ids ...uint64) []bool {
+	handled := make(chan struct{})
+	select {
+	case watch.sync <- handled:
+		<-handled
+	case <-watch.done:
+	}
+	watch.mutex.Lock()
+	defer watch.mutex.Unlock()
+	returned := make([]bool, len(ids))
+	for idx, id := range ids {
+		returned[idx] = watch.awaited[id]
+		delete(watch.awaited, id)
+	}
+	return returned
+}
+
+// forget stops awaiting return of publishes which failed or were not confirmed.
+func (watch *returnWatch) forget(ids ...uint64) {
+	watch.mutex.Lock()
+	defer watch.mutex.Unlock()
+	for _, id := range ids {
+		delete(watch.awaited, id)
+	}
+}
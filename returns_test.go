@@ -0,0 +1,60 @@
+package k9amqp
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// TestReturnedBeforeConfirm sends the return and then the confirm the way amqp091 reader does,
+// the return must be matched to its publish even when handling the return is slow.
+func TestReturnedBeforeConfirm(t *testing.T) {
+	watch := newReturnWatch()
+	returns := make(chan amqp.Return)
+	defer close(returns)
+	go watch.run(returns, func(amqp.Return) {
+		time.Sleep(time.Millisecond)
+	})
+	for idx := range 20 {
+		routed := idx%2 == 0
+		headers, id := watch.await(nil)
+		confirm := make(chan struct{})
+		go func() {
+			if !routed {
+				returns <- amqp.Return{Headers: headers}
+			}
+			close(confirm)
+		}()
+		<-confirm
+		if got := watch.wasReturned(id)[0]; got == routed {
+			t.Fatalf("publish %d returned %t, want %t", idx, got, !routed)
+		}
+	}
+	if len(watch.awaited) != 0 {
+		t.Errorf("%d awaited publishes left", len(watch.awaited))
+	}
+}
+
+func TestReturnedOtherPublish(t *testing.T) {
+	watch := newReturnWatch()
+	returns := make(chan amqp.Return)
+	go watch.run(returns, func(amqp.Return) {})
+	first, firstID := watch.await(amqp.Table{"tenant": "a"})
+	_, secondID := watch.await(nil)
+	returns <- amqp.Return{Headers: first}
+	returns <- amqp.Return{Headers: amqp.Table{}}
+	if got := watch.wasReturned(firstID, secondID); !slices.Equal(got, []bool{true, false}) {
+		t.Errorf("wasReturned = %v, want [true false]", got)
+	}
+	if first["tenant"] != "a" {
+		t.Error("headers of the message not kept")
+	}
+	close(returns)
+	<-watch.done
+	_, id := watch.await(nil)
+	if watch.wasReturned(id)[0] {
+		t.Error("publish returned after watch stopped")
+	}
+}
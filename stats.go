@@ -0,0 +1,73 @@
+package k9amqp
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const poolStatsInterval = time.Second
+
+// endpointStats counts pool channels of connections to a single node.
+type endpointStats struct {
+	idle          atomic.Int64
+	opened        atomic.Int64
+	closedOnError atomic.Int64
+}
+
+// poolStats is a per node pool snapshot, counters hold increments since the last snapshot.
+type poolStats struct {
+	endpoint      amqpEndpoint
+	connections   int
+	idle          int64
+	opened        int64
+	closedOnError int64
+}
+
+func newPoolStats(endpoints []amqpEndpoint) map[string]*endpointStats {
+	stats := make(map[string]*endpointStats, len(endpoints))
+	for _, endpoint := range endpoints {
+		stats[endpoint.String()] = &endpointStats{}
+	}
+	return stats
+}
+
+func (p *AmqpPool) endpointStats(endpoint amqpEndpoint) *endpointStats {
+	if stats, ok := p.stats[endpoint.String()]; ok {
+		return stats
+	}
+	return &endpointStats{}
+}
+
+// snapshot returns pool statistics at most once per poolStatsInterval, nil otherwise,
+// so only one of concurrent VUs reports them.
+func (p *AmqpPool) snapshot(endpoints []amqpEndpoint) []poolStats {
+	now := time.Now().UnixNano()
+	last := p.lastSnapshot.Load()
+	if now-last < int64(poolStatsInterval) || !p.lastSnapshot.CompareAndSwap(last, now) {
+		return nil
+	}
+	connections := make(map[string]int, len(endpoints))
+	for _, conn := range p.conns() {
+		if !conn.IsClosed() {
+			connections[conn.endpoint.String()]++
+		}
+	}
+	snapshot := make([]poolStats, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		stats := p.endpointStats(endpoint)
+		snapshot = append(snapshot, poolStats{
+			endpoint:      endpoint,
+			connections:   connections[endpoint.String()],
+			idle:          stats.idle.Load(),
+			opened:        stats.opened.Swap(0),
+			closedOnError: stats.closedOnError.Swap(0),
+		})
+	}
+	return snapshot
+}
+
+// blow closes channel after an error.
+func (channel *amqpChannel) blow() error {
+	channel.pool.endpointStats(channel.endpoint).closedOnError.Add(1)
+	return channel.Close()
+}
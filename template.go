@@ -0,0 +1,218 @@
+package k9amqp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// templateCacheSize bounds cached templates, so scripts rendering unique texts don't grow the cache.
+const templateCacheSize = 1024
+
+var errTemplate = errors.New("invalid message template")
+
+var templates = templateCache{compiled: make(map[string]*template)}
+
+// template is a compiled message template, literal text interleaved with placeholders.
+type template struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	literal string
+	render  func(*templateContext, []byte) []byte
+}
+
+// templateContext holds values of a single message, so placeholders render the same value in body and properties.
+type templateContext struct {
+	vu   uint64
+	iter int64
+	seq  uint64
+	now  time.Time
+	uuid string
+}
+
+// vuOrigin is the VU and its iteration a background publish was started by.
+type vuOrigin struct {
+	vu        uint64
+	iteration int64
+}
+
+type templateCache struct {
+	mutex    sync.RWMutex
+	compiled map[string]*template
+}
+
+// get returns compiled template of the text, templates are compiled once and cached.
+func (cache *templateCache) get(text []byte) (*template, error) {
+	cache.mutex.RLock()
+	compiled, ok := cache.compiled[string(text)]
+	cache.mutex.RUnlock()
+	if ok {
+		return compiled, nil
+	}
+	compiled, err := compileTemplate(string(text))
+	if err != nil {
+		return nil, err
+	}
+	cache.mutex.Lock()
+	if len(cache.compiled) < templateCacheSize {
+		cache.compiled[string(text)] = compiled
+	}
+	cache.mutex.Unlock()
+	return compiled, nil
+}
+
+func compileTemplate(text string) (*template, error) {
+	compiled := &template{}
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(text[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed template placeholder '%s'", text[start:])
+		}
+		render, err := placeholder(strings.Fields(text[start+2 : start+end]))
+		if err != nil {
+			return nil, err
+		}
+		if start > 0 {
+			compiled.parts = append(compiled.parts, templatePart{literal: text[:start]})
+		}
+		compiled.parts = append(compiled.parts, templatePart{render: render})
+		text = text[start+end+2:]
+	}
+	if text != "" {
+		compiled.parts = append(compiled.parts, templatePart{literal: text})
+	}
+	return compiled, nil
+}
+
+func placeholder(fields []string) (func(*templateContext, []byte) []byte, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("empty template placeholder")
+	}
+	name, args := fields[0], fields[1:]
+	if name != "random_int" && len(args) > 0 {
+		return nil, fmt.Errorf("template placeholder '%s' takes no arguments", name)
+	}
+	switch name {
+	case "uuid":
+		return func(ctx *templateContext, dst []byte) []byte {
+			if ctx.uuid == "" {
+				ctx.uuid = uuid.NewString()
+			}
+			return append(dst, ctx.uuid...)
+		}, nil
+	case "seq":
+		return func(ctx *templateContext, dst []byte) []byte {
+			return strconv.AppendUint(dst, ctx.seq, 10)
+		}, nil
+	case "now_ms":
+		return func(ctx *templateContext, dst []byte) []byte {
+			return strconv.AppendInt(dst, ctx.now.UnixMilli(), 10)
+		}, nil
+	case "vu":
+		return func(ctx *templateContext, dst []byte) []byte {
+			return strconv.AppendUint(dst, ctx.vu, 10)
+		}, nil
+	case "iter":
+		return func(ctx *templateContext, dst []byte) []byte {
+			return strconv.AppendInt(dst, ctx.iter, 10)
+		}, nil
+	case "random_int":
+		if len(args) != 2 {
+			return nil, errors.New("template placeholder 'random_int' takes min and max arguments")
+		}
+		low, lowErr := strconv.Atoi(args[0])
+		high, highErr := strconv.Atoi(args[1])
+		if lowErr != nil || highErr != nil || high < low {
+			return nil, fmt.Errorf("invalid 'random_int' range '%s %s'", args[0], args[1])
+		}
+		return func(_ *templateContext, dst []byte) []byte {
+			return strconv.AppendInt(dst, int64(low+rand.Intn(high-low+1)), 10)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown template placeholder '%s'", name)
+	}
+}
+
+// templateContext returns values of the next templated message published by the client, publishes
+// made off the VU goroutine use VU id and iteration recorded when they started.
+func (client *Client) templateContext(origin *vuOrigin) *templateContext {
+	ctx := &templateContext{seq: client.seq.Add(1), now: time.Now()}
+	if origin != nil {
+		ctx.vu, ctx.iter = origin.vu, origin.iteration
+	} else if state := client.k9amqp.vu.State(); state != nil {
+		ctx.vu, ctx.iter = state.VUID, state.Iteration
+	}
+	return ctx
+}
+
+func (compiled *template) render(ctx *templateContext, dst []byte) []byte {
+	for _, part := range compiled.parts {
+		if part.render != nil {
+			dst = part.render(ctx, dst)
+		} else {
+			dst = append(dst, part.literal...)
+		}
+	}
+	return dst
+}
+
+func renderTemplate(ctx *templateContext, text []byte) ([]byte, error) {
+	if !bytes.Contains(text, []byte("{{")) {
+		return text, nil
+	}
+	compiled, err := templates.get(text)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.render(ctx, make([]byte, 0, len(text)+32)), nil
+}
+
+func renderString(ctx *templateContext, text string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	rendered, err := renderTemplate(ctx, []byte(text))
+	return string(rendered), err
+}
+
+// renderMessage renders templates of body, string headers, message_id and correlation_id of the message.
+func renderMessage(ctx *templateContext, msg amqp.Publishing) (amqp.Publishing, error) {
+	var err error
+	if msg.Body, err = renderTemplate(ctx, msg.Body); err != nil {
+		return msg, err
+	}
+	if msg.MessageId, err = renderString(ctx, msg.MessageId); err != nil {
+		return msg, err
+	}
+	if msg.CorrelationId, err = renderString(ctx, msg.CorrelationId); err != nil {
+		return msg, err
+	}
+	if len(msg.Headers) == 0 {
+		return msg, nil
+	}
+	headers := make(amqp.Table, len(msg.Headers))
+	for key, value := range msg.Headers {
+		if text, ok := value.(string); ok {
+			if value, err = renderString(ctx, text); err != nil {
+				return msg, err
+			}
+		}
+		headers[key] = value
+	}
+	msg.Headers = headers
+	return msg, nil
+}
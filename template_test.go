@@ -0,0 +1,102 @@
+package k9amqp
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	ctx := &templateContext{vu: 3, iter: 7, seq: 42, now: time.UnixMilli(1700000000123)}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"{{seq}}", "42"},
+		{"vu {{vu}} iter {{ iter }}", "vu 3 iter 7"},
+		{`{"ts":{{now_ms}},"seq":{{seq}}}`, `{"ts":1700000000123,"seq":42}`},
+		{"{{random_int 5 5}}", "5"},
+	}
+	for _, test := range tests {
+		got, err := renderTemplate(ctx, []byte(test.text))
+		if err != nil {
+			t.Errorf("renderTemplate(%q) failed: %v", test.text, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("renderTemplate(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestRenderRandomInt(t *testing.T) {
+	compiled, err := compileTemplate("{{random_int -2 2}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 100 {
+		value, err := strconv.Atoi(string(compiled.render(&templateContext{}, nil)))
+		if err != nil || value < -2 || value > 2 {
+			t.Fatalf("random_int rendered %d, %v, want -2..2", value, err)
+		}
+	}
+}
+
+func TestCompileTemplateInvalid(t *testing.T) {
+	for _, text := range []string{
+		"{{seq",
+		"{{}}",
+		"{{unknown}}",
+		"{{seq 1}}",
+		"{{random_int 1}}",
+		"{{random_int 5 1}}",
+		"{{random_int a b}}",
+	} {
+		if _, err := compileTemplate(text); err == nil {
+			t.Errorf("template %q compiled", text)
+		}
+	}
+}
+
+func TestRenderMessage(t *testing.T) {
+	ctx := &templateContext{seq: 9}
+	msg := amqp.Publishing{
+		Body:          []byte("order {{uuid}}"),
+		MessageId:     "{{uuid}}",
+		CorrelationId: "corr-{{seq}}",
+		Headers:       amqp.Table{"seq": "{{seq}}", "count": int32(1)},
+	}
+	headers := msg.Headers
+	rendered, err := renderMessage(ctx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rendered.MessageId) != 36 || string(rendered.Body) != "order "+rendered.MessageId {
+		t.Errorf("uuid rendered differently in body %q and message_id %q", rendered.Body, rendered.MessageId)
+	}
+	if rendered.CorrelationId != "corr-9" || rendered.Headers["seq"] != "9" || rendered.Headers["count"] != int32(1) {
+		t.Errorf("rendered correlation_id %q, headers %v", rendered.CorrelationId, rendered.Headers)
+	}
+	if headers["seq"] != "{{seq}}" {
+		t.Error("headers of the script message modified")
+	}
+	if _, err = renderMessage(ctx, amqp.Publishing{Headers: amqp.Table{"bad": "{{nope}}"}}); err == nil {
+		t.Error("invalid header template rendered")
+	}
+}
+
+func TestTemplateCacheBounded(t *testing.T) {
+	cache := templateCache{compiled: make(map[string]*template)}
+	for idx := range templateCacheSize + 10 {
+		if _, err := cache.get([]byte("{{seq}}-" + strings.Repeat("x", idx))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(cache.compiled) != templateCacheSize {
+		t.Errorf("cache holds %d templates, want %d", len(cache.compiled), templateCacheSize)
+	}
+}
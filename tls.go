@@ -0,0 +1,57 @@
+package k9amqp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// config builds client TLS configuration, nil is returned when TLS is disabled.
+func (opt *TLSOptions) config() (*tls.Config, error) {
+	if !opt.Enabled {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         opt.ServerName,
+		InsecureSkipVerify: opt.InsecureSkipVerify, //nolint:gosec // opt-in for lab brokers with self-signed certificates
+		MinVersion:         tls.VersionTLS12,
+	}
+	if opt.MinVersion != "" {
+		version, ok := tlsVersions[opt.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls min_version '%s'", opt.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+	if opt.CaFile != "" {
+		pem, err := os.ReadFile(opt.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read tls ca_file: %w", err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in tls ca_file '%s'", opt.CaFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	if opt.CertFile != "" || opt.KeyFile != "" {
+		if opt.CertFile == "" || opt.KeyFile == "" {
+			return nil, errors.New("both tls cert_file and key_file are required for client certificate")
+		}
+		cert, err := tls.LoadX509KeyPair(opt.CertFile, opt.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load tls client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
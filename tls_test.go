@@ -0,0 +1,129 @@
+package k9amqp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert generates self-signed certificate and writes it and its key as PEM files.
+func writeCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "rabbitmq"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	certFile, keyFile := writeCert(t)
+	opts := TLSOptions{
+		Enabled:            true,
+		CaFile:             certFile,
+		CertFile:           certFile,
+		KeyFile:            keyFile,
+		ServerName:         "rabbitmq",
+		MinVersion:         "1.3",
+		InsecureSkipVerify: true,
+	}
+	config, err := opts.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.RootCAs == nil || len(config.Certificates) != 1 {
+		t.Errorf("root CAs %v, %d client certificates, want CA and 1 certificate", config.RootCAs, len(config.Certificates))
+	}
+	if config.ServerName != "rabbitmq" || config.MinVersion != tls.VersionTLS13 || !config.InsecureSkipVerify {
+		t.Errorf("server name %q, min version %x, skip verify %t", config.ServerName, config.MinVersion, config.InsecureSkipVerify)
+	}
+}
+
+func TestTLSConfigDefaults(t *testing.T) {
+	if config, err := (&TLSOptions{}).config(); config != nil || err != nil {
+		t.Errorf("disabled tls config %v, %v, want nil", config, err)
+	}
+	config, err := (&TLSOptions{Enabled: true}).config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MinVersion != tls.VersionTLS12 || config.InsecureSkipVerify || config.RootCAs != nil || len(config.Certificates) != 0 {
+		t.Errorf("default tls config %+v", config)
+	}
+}
+
+func TestTLSConfigInvalid(t *testing.T) {
+	certFile, keyFile := writeCert(t)
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, opts := range map[string]TLSOptions{
+		"min version":  {MinVersion: "1.4"},
+		"ca missing":   {CaFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"ca garbage":   {CaFile: garbage},
+		"cert only":    {CertFile: certFile},
+		"key only":     {KeyFile: keyFile},
+		"key mismatch": {CertFile: certFile, KeyFile: garbage},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts.Enabled = true
+			if _, err := opts.config(); err == nil {
+				t.Errorf("tls options %+v accepted", opts)
+			}
+		})
+	}
+}
+
+func TestTLSSchemeMismatch(t *testing.T) {
+	for name, opts := range map[string]AmqpOptions{
+		"uri":   {URI: "amqp://rabbitmq", TLS: TLSOptions{Enabled: true}},
+		"uris":  {URIs: []string{"amqps://rabbitmq-1", "amqp://rabbitmq-2"}, TLS: TLSOptions{Enabled: true}},
+		"nodes": {Nodes: []NodeOptions{{URI: "amqp://rabbitmq"}}, TLS: TLSOptions{Enabled: true}},
+	} {
+		if err := opts.init(); err == nil {
+			t.Errorf("%s: tls enabled with amqp uri accepted", name)
+		}
+	}
+	opts := AmqpOptions{Host: "rabbitmq", TLS: TLSOptions{Enabled: true}}
+	if err := opts.init(); err != nil {
+		t.Fatal(err)
+	}
+	if uri := opts.endpoints[0].uri; uri.Scheme != "amqps" || uri.Port != 5671 {
+		t.Errorf("tls enabled host dials %s port %d, want amqps port 5671", uri.Scheme, uri.Port)
+	}
+}
@@ -0,0 +1,160 @@
+package k9amqp
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// topology records exchanges, queues and bindings declared by the client, so they can be
+// declared again once a lost connection is recovered.
+type topology struct {
+	mutex            sync.Mutex
+	exchanges        []ExchangeDeclareOptions
+	queues           []QueueDeclareOptions
+	queueBindings    []QueueBindOptions
+	exchangeBindings []ExchangeBindOptions
+}
+
+func (t *topology) declareExchange(opts ExchangeDeclareOptions) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.exchanges = slices.DeleteFunc(t.exchanges, func(e ExchangeDeclareOptions) bool { return e.Name == opts.Name })
+	t.exchanges = append(t.exchanges, opts)
+}
+
+func (t *topology) deleteExchange(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.exchanges = slices.DeleteFunc(t.exchanges, func(e ExchangeDeclareOptions) bool { return e.Name == name })
+	t.queueBindings = slices.DeleteFunc(t.queueBindings, func(b QueueBindOptions) bool { return b.Exchange == name })
+	t.exchangeBindings = slices.DeleteFunc(t.exchangeBindings, func(b ExchangeBindOptions) bool {
+		return b.Source == name || b.Destination == name
+	})
+}
+
+func (t *topology) declareQueue(opts QueueDeclareOptions) {
+	if opts.Passive {
+		return
+	}
+	if opts.Name == "" {
+		slog.Debug("server-named queue is not recorded for topology recovery")
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.queues = slices.DeleteFunc(t.queues, func(q QueueDeclareOptions) bool { return q.Name == opts.Name })
+	t.queues = append(t.queues, opts)
+}
+
+func (t *topology) deleteQueue(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.queues = slices.DeleteFunc(t.queues, func(q QueueDeclareOptions) bool { return q.Name == name })
+	t.queueBindings = slices.DeleteFunc(t.queueBindings, func(b QueueBindOptions) bool { return b.Name == name })
+}
+
+func (t *topology) bindQueue(opts QueueBindOptions) {
+	t.unbindQueue(opts.Name, opts.Key, opts.Exchange)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.queueBindings = append(t.queueBindings, opts)
+}
+
+func (t *topology) unbindQueue(name, key, exchange string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.queueBindings = slices.DeleteFunc(t.queueBindings, func(b QueueBindOptions) bool {
+		return b.Name == name && b.Key == key && b.Exchange == exchange
+	})
+}
+
+func (t *topology) bindExchange(opts ExchangeBindOptions) {
+	t.unbindExchange(opts.Destination, opts.Key, opts.Source)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.exchangeBindings = append(t.exchangeBindings, opts)
+}
+
+func (t *topology) unbindExchange(destination, key, source string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.exchangeBindings = slices.DeleteFunc(t.exchangeBindings, func(b ExchangeBindOptions) bool {
+		return b.Destination == destination && b.Key == key && b.Source == source
+	})
+}
+
+// replayable returns copy of the recorded topology to declare on a recovered connection. Exclusive
+// queues and their bindings are left out, the queue is owned by the connection which declared it.
+func (t *topology) replayable() (exchanges []ExchangeDeclareOptions, queues []QueueDeclareOptions,
+	queueBindings []QueueBindOptions, exchangeBindings []ExchangeBindOptions) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	exclusive := make(map[string]bool)
+	for _, q := range t.queues {
+		if q.Exclusive {
+			exclusive[q.Name] = true
+		} else {
+			queues = append(queues, q)
+		}
+	}
+	for _, b := range t.queueBindings {
+		if !exclusive[b.Name] {
+			queueBindings = append(queueBindings, b)
+		}
+	}
+	return slices.Clone(t.exchanges), queues, queueBindings, slices.Clone(t.exchangeBindings)
+}
+
+// replay declares the recorded topology using the connection, a failed declaration
+// closes the channel so a new one is opened for the next one.
+func (t *topology) replay(conn *amqp.Connection) (recovered int, failed int) {
+	exchanges, queues, queueBindings, exchangeBindings := t.replayable()
+
+	var channel *amqp.Channel
+	declare := func(kind, name string, fn func(*amqp.Channel) error) {
+		var err error
+		if channel == nil || channel.IsClosed() {
+			if channel, err = conn.Channel(); err != nil {
+				slog.Error("topology recovery failed to open channel", "error", err)
+				failed++
+				return
+			}
+		}
+		if err = fn(channel); err != nil {
+			slog.Error("topology recovery failed", kind, name, "error", err)
+			failed++
+			return
+		}
+		recovered++
+	}
+	for _, e := range exchanges {
+		declare("exchange", e.Name, func(ch *amqp.Channel) error {
+			return ch.ExchangeDeclare(e.Name, e.Kind, e.Durable, e.AutoDelete, e.Internal, e.NoWait, e.Args)
+		})
+	}
+	for _, q := range queues {
+		declare("queue", q.Name, func(ch *amqp.Channel) error {
+			_, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, q.NoWait, q.Args)
+			return err
+		})
+	}
+	for _, b := range queueBindings {
+		declare("queue_binding", b.Name, func(ch *amqp.Channel) error {
+			return ch.QueueBind(b.Name, b.Key, b.Exchange, b.NoWait, b.Args)
+		})
+	}
+	for _, b := range exchangeBindings {
+		declare("exchange_binding", b.Destination, func(ch *amqp.Channel) error {
+			return ch.ExchangeBind(b.Destination, b.Key, b.Source, b.NoWait, b.Args)
+		})
+	}
+	if channel != nil && !channel.IsClosed() {
+		if err := channel.Close(); err != nil {
+			slog.Error("failed to close topology recovery channel", "error", err)
+		}
+	}
+	return recovered, failed
+}
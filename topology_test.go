@@ -0,0 +1,36 @@
+package k9amqp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTopologyReplayable(t *testing.T) {
+	var topo topology
+	topo.declareExchange(ExchangeDeclareOptions{Name: "orders", Kind: "topic"})
+	topo.declareQueue(QueueDeclareOptions{Name: "shared", Durable: true})
+	topo.declareQueue(QueueDeclareOptions{Name: "temporary", AutoDelete: true})
+	topo.declareQueue(QueueDeclareOptions{Name: "private", Exclusive: true})
+	topo.declareQueue(QueueDeclareOptions{Name: "passive", Passive: true})
+	topo.bindQueue(QueueBindOptions{Name: "shared", Key: "#", Exchange: "orders"})
+	topo.bindQueue(QueueBindOptions{Name: "private", Key: "#", Exchange: "orders"})
+	topo.bindExchange(ExchangeBindOptions{Destination: "orders", Source: "amq.topic", Key: "#"})
+
+	exchanges, queues, queueBindings, exchangeBindings := topo.replayable()
+	if len(exchanges) != 1 || len(exchangeBindings) != 1 {
+		t.Errorf("replayable %d exchanges, %d exchange bindings, want 1, 1", len(exchanges), len(exchangeBindings))
+	}
+	names := make([]string, 0, len(queues))
+	for _, q := range queues {
+		names = append(names, q.Name)
+	}
+	if want := []string{"shared", "temporary"}; !slices.Equal(names, want) {
+		t.Errorf("replayable queues %v, want %v", names, want)
+	}
+	if len(queueBindings) != 1 || queueBindings[0].Name != "shared" {
+		t.Errorf("replayable queue bindings %+v, want binding of shared", queueBindings)
+	}
+	if len(topo.queues) != 3 || len(topo.queueBindings) != 2 {
+		t.Errorf("recorded %d queues, %d bindings, want 3, 2", len(topo.queues), len(topo.queueBindings))
+	}
+}
@@ -0,0 +1,278 @@
+package k9amqp
+
+import (
+	"log/slog"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.k6.io/k6/v2/metrics"
+)
+
+const (
+	producerHeader = "x-k9amqp-producer"
+	seqHeader      = "x-k9amqp-seq"
+	channelHeader  = "x-k9amqp-channel"
+
+	// reorderWindow bounds sequences received above a missing one, the missing sequences
+	// are counted lost once the window is exceeded.
+	reorderWindow = 65536
+)
+
+// tracker is shared by all clients of the process, so messages published by one client
+// and consumed by another are matched.
+var tracker = newMessageTracker()
+
+// messageTracker detects lost, duplicated and reordered messages by producer sequences.
+type messageTracker struct {
+	mutex     sync.Mutex
+	producers map[string]*producerTrack
+	sources   map[string]*trackSource
+	reported  bool
+}
+
+// producerTrack holds sequences received from a producer, watermark is the lowest sequence not
+// received yet and pending are received sequences above it. Messages of a producer published on
+// different channels interleave, so order is checked by the highest sequence of each channel.
+type producerTrack struct {
+	received   int64
+	max        uint64
+	watermark  uint64
+	pending    map[uint64]struct{}
+	channels   map[int64]uint64
+	lost       int64
+	duplicated int64
+	outOfOrder int64
+}
+
+// trackResult counts duplicated, out of order and lost deliveries of a single receive.
+type trackResult struct {
+	duplicated int64
+	outOfOrder int64
+	lost       int64
+}
+
+// trackReport summarizes tracked messages of all producers, Lost includes Evicted sequences
+// counted lost already when reorder window was exceeded.
+type trackReport struct {
+	Producers  int
+	Sent       int64
+	Received   int64
+	Lost       int64
+	Evicted    int64
+	Duplicated int64
+	OutOfOrder int64
+}
+
+func newMessageTracker() *messageTracker {
+	return &messageTracker{producers: make(map[string]*producerTrack), sources: make(map[string]*trackSource)}
+}
+
+func (t *messageTracker) producer(id string) *producerTrack {
+	track, ok := t.producers[id]
+	if !ok {
+		track = &producerTrack{watermark: 1, pending: make(map[uint64]struct{}), channels: make(map[int64]uint64)}
+		t.producers[id] = track
+	}
+	return track
+}
+
+// newSource registers a producer of tracked messages, its sent messages are counted by the source.
+func (t *messageTracker) newSource() *trackSource {
+	source := &trackSource{id: uuid.NewString()}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.sources[source.id] = source
+	return source
+}
+
+func (t *messageTracker) receive(deliveries []amqp.Delivery) trackResult {
+	var result trackResult
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for idx := range deliveries {
+		producer, seq, ok := trackedSeq(&deliveries[idx])
+		if !ok {
+			continue
+		}
+		track := t.producer(producer)
+		if _, seen := track.pending[seq]; seen || seq < track.watermark {
+			track.duplicated++
+			result.duplicated++
+			continue
+		}
+		ordered := track.max
+		channel, perChannel := deliveries[idx].Headers[channelHeader].(int64)
+		if perChannel {
+			ordered = track.channels[channel]
+			track.channels[channel] = max(ordered, seq)
+		}
+		if seq < ordered {
+			track.outOfOrder++
+			result.outOfOrder++
+		}
+		track.received++
+		track.max = max(track.max, seq)
+		track.pending[seq] = struct{}{}
+		if track.max-track.watermark >= reorderWindow {
+			lost := track.evict(track.max - reorderWindow + 1)
+			track.lost += lost
+			result.lost += lost
+		}
+		for {
+			if _, ok := track.pending[track.watermark]; !ok {
+				break
+			}
+			delete(track.pending, track.watermark)
+			track.watermark++
+		}
+	}
+	return result
+}
+
+// evict moves watermark to the sequence, missing sequences below it are returned as lost.
+func (track *producerTrack) evict(watermark uint64) int64 {
+	var lost int64
+	for ; track.watermark < watermark; track.watermark++ {
+		if _, ok := track.pending[track.watermark]; ok {
+			delete(track.pending, track.watermark)
+		} else {
+			lost++
+		}
+	}
+	return lost
+}
+
+// report summarizes tracked messages, lost messages are counted only by the first report.
+func (t *messageTracker) report() (trackReport, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	report := trackReport{Producers: len(t.producers)}
+	for id, track := range t.producers {
+		var sent int64
+		if source, ok := t.sources[id]; ok {
+			sent = source.sent.Load()
+		}
+		report.Sent += sent
+		report.Received += track.received
+		report.Evicted += track.lost
+		report.Duplicated += track.duplicated
+		report.OutOfOrder += track.outOfOrder
+		// producers of other processes are unknown to the tracker, their losses are gaps in sequence
+		expected := max(sent, int64(track.max)) //nolint:gosec // sequences don't overflow int64
+		report.Lost += max(expected-track.received, 0)
+	}
+	for id, source := range t.sources {
+		if _, ok := t.producers[id]; !ok && source.sent.Load() > 0 {
+			// nothing received from the source
+			report.Producers++
+			report.Sent += source.sent.Load()
+			report.Lost += source.sent.Load()
+		}
+	}
+	first := !t.reported
+	t.reported = true
+	return report, first
+}
+
+func trackedSeq(delivery *amqp.Delivery) (string, uint64, bool) {
+	producer, ok := delivery.Headers[producerHeader].(string)
+	if !ok {
+		return "", 0, false
+	}
+	switch seq := delivery.Headers[seqHeader].(type) {
+	case int64:
+		return producer, uint64(seq), seq > 0
+	case int32:
+		return producer, uint64(seq), seq > 0
+	default:
+		return "", 0, false
+	}
+}
+
+// trackSource is a producer of tracked messages, its sequences are expected to be received in order.
+type trackSource struct {
+	id   string
+	seq  atomic.Uint64
+	sent atomic.Int64
+}
+
+// stamp returns copy of the headers with producer id, the next sequence of the source and id of
+// the channel the message is published on.
+func (source *trackSource) stamp(headers amqp.Table, channel uint64) amqp.Table {
+	stamped := make(amqp.Table, len(headers)+3)
+	maps.Copy(stamped, headers)
+	stamped[producerHeader] = source.id
+	stamped[seqHeader] = int64(source.seq.Add(1))
+	stamped[channelHeader] = int64(channel) //nolint:gosec // channel ids don't overflow int64
+	return stamped
+}
+
+// track feeds tracker with received deliveries and reports duplicated and out of order ones.
+func (k9amqp *K9amqp) track(deliveries []amqp.Delivery) {
+	result := tracker.receive(deliveries)
+	if result == (trackResult{}) || k9amqp.vu.State() == nil {
+		return
+	}
+	k9amqp.trackReporter()(result)
+}
+
+// trackReporter captures VU metrics context, so deliveries received in background are reported.
+func (k9amqp *K9amqp) trackReporter() func(trackResult) {
+	state := k9amqp.vu.State()
+	if state == nil {
+		return func(trackResult) {}
+	}
+	ctx := k9amqp.vu.Context()
+	ctm := state.Tags.GetCurrentValues()
+	return func(result trackResult) {
+		now := time.Now()
+		samples := make([]metrics.Sample, 0, 3)
+		for metric, value := range map[*metrics.Metric]int64{
+			k9amqp.metrics.MsgDuplicated: result.duplicated,
+			k9amqp.metrics.MsgOutOfOrder: result.outOfOrder,
+			k9amqp.metrics.MsgLost:       result.lost,
+		} {
+			if value == 0 {
+				continue
+			}
+			samples = append(samples, metrics.Sample{
+				Time: now,
+				TimeSeries: metrics.TimeSeries{
+					Metric: metric,
+					Tags:   ctm.Tags,
+				},
+				Value:    float64(value),
+				Metadata: ctm.Metadata,
+			})
+		}
+		metrics.PushIfNotDone(ctx, state.Samples, metrics.ConnectedSamples{Samples: samples})
+	}
+}
+
+// reportTracked logs tracker summary and pushes lost messages once per process, except of those
+// pushed when reorder window was exceeded.
+func (k9amqp *K9amqp) reportTracked() {
+	report, first := tracker.report()
+	if report.Producers == 0 {
+		return
+	}
+	slog.Info("message tracking report", "producers", report.Producers, "sent", report.Sent, "received", report.Received,
+		"lost", report.Lost, "duplicated", report.Duplicated, "out_of_order", report.OutOfOrder)
+	if !first || k9amqp.vu.State() == nil {
+		return
+	}
+	ctm := k9amqp.vu.State().Tags.GetCurrentValues()
+	metrics.PushIfNotDone(k9amqp.vu.Context(), k9amqp.vu.State().Samples, metrics.Sample{
+		Time: time.Now(),
+		TimeSeries: metrics.TimeSeries{
+			Metric: k9amqp.metrics.MsgLost,
+			Tags:   ctm.Tags,
+		},
+		Value:    float64(max(report.Lost-report.Evicted, 0)),
+		Metadata: ctm.Metadata,
+	})
+}
@@ -0,0 +1,133 @@
+package k9amqp
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func trackedDelivery(producer string, seq int64) amqp.Delivery {
+	return amqp.Delivery{Headers: amqp.Table{producerHeader: producer, seqHeader: seq}}
+}
+
+func receiveSeqs(t *messageTracker, producer string, seqs ...int64) trackResult {
+	deliveries := make([]amqp.Delivery, len(seqs))
+	for idx, seq := range seqs {
+		deliveries[idx] = trackedDelivery(producer, seq)
+	}
+	return t.receive(deliveries)
+}
+
+func TestTrackerReceive(t *testing.T) {
+	tests := []struct {
+		name string
+		seqs []int64
+		want trackResult
+		lost int64
+	}{
+		{"ordered", []int64{1, 2, 3}, trackResult{}, 0},
+		{"duplicated", []int64{1, 2, 2, 1}, trackResult{duplicated: 2}, 0},
+		{"out of order", []int64{1, 3, 2, 4}, trackResult{outOfOrder: 1}, 0},
+		{"out of order duplicated", []int64{1, 3, 3, 2}, trackResult{duplicated: 1, outOfOrder: 1}, 0},
+		{"gap", []int64{1, 2, 5}, trackResult{}, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := newMessageTracker()
+			if got := receiveSeqs(tracker, "p", test.seqs...); got != test.want {
+				t.Errorf("receive %v = %+v, want %+v", test.seqs, got, test.want)
+			}
+			report, first := tracker.report()
+			if !first || report.Lost != test.lost {
+				t.Errorf("report lost %d, first %t, want %d, true", report.Lost, first, test.lost)
+			}
+		})
+	}
+}
+
+func TestTrackerUntracked(t *testing.T) {
+	tracker := newMessageTracker()
+	deliveries := []amqp.Delivery{{}, {Headers: amqp.Table{producerHeader: "p"}}, {Headers: amqp.Table{producerHeader: "p", seqHeader: "1"}}}
+	if got := tracker.receive(deliveries); got != (trackResult{}) || len(tracker.producers) != 0 {
+		t.Errorf("untracked deliveries tracked: %+v, %d producers", got, len(tracker.producers))
+	}
+}
+
+func channelDelivery(producer string, channel, seq int64) amqp.Delivery {
+	delivery := trackedDelivery(producer, seq)
+	delivery.Headers[channelHeader] = channel
+	return delivery
+}
+
+// TestTrackerChannels receives messages of a producer published on two channels, channel 1 is
+// consumed ahead of channel 2.
+func TestTrackerChannels(t *testing.T) {
+	tracker := newMessageTracker()
+	got := tracker.receive([]amqp.Delivery{
+		channelDelivery("p", 1, 1), channelDelivery("p", 1, 3), channelDelivery("p", 1, 5),
+		channelDelivery("p", 2, 2), channelDelivery("p", 2, 4), channelDelivery("p", 2, 6),
+	})
+	if got != (trackResult{}) {
+		t.Errorf("interleaved channels = %+v, want nothing out of order", got)
+	}
+	got = tracker.receive([]amqp.Delivery{channelDelivery("p", 1, 9), channelDelivery("p", 1, 7)})
+	if got.outOfOrder != 1 {
+		t.Errorf("reordered channel = %+v, want out of order", got)
+	}
+}
+
+// TestTrackerReorderWindow loses one message, sequences received after it must not be kept for the rest of the run.
+func TestTrackerReorderWindow(t *testing.T) {
+	tracker := newMessageTracker()
+	var lost int64
+	for seq := int64(2); seq <= 3*reorderWindow; seq++ {
+		lost += receiveSeqs(tracker, "p", seq).lost
+	}
+	track := tracker.producers["p"]
+	if lost != 1 || track.lost != 1 {
+		t.Errorf("lost %d, tracked %d, want 1", lost, track.lost)
+	}
+	if len(track.pending) > reorderWindow {
+		t.Errorf("%d sequences pending, want at most %d", len(track.pending), reorderWindow)
+	}
+	if got := receiveSeqs(tracker, "p", 1); got.duplicated != 1 {
+		t.Errorf("sequence received after reorder window = %+v, want duplicated", got)
+	}
+	report, _ := tracker.report()
+	if report.Lost != 1 || report.Evicted != 1 {
+		t.Errorf("report lost %d, evicted %d, want 1, 1", report.Lost, report.Evicted)
+	}
+}
+
+func TestTrackerReport(t *testing.T) {
+	tracker := newMessageTracker()
+	source := tracker.newSource()
+	silent := tracker.newSource()
+	for range 5 {
+		source.sent.Add(1)
+	}
+	silent.sent.Add(2)
+	receiveSeqs(tracker, source.id, 1, 2, 4)
+	receiveSeqs(tracker, "remote", 1, 3)
+	report, first := tracker.report()
+	want := trackReport{Producers: 3, Sent: 7, Received: 5, Lost: 2 + 2 + 1}
+	if !first || report != want {
+		t.Errorf("report %+v, first %t, want %+v, true", report, first, want)
+	}
+	if _, first = tracker.report(); first {
+		t.Error("second report is reported as first")
+	}
+}
+
+func TestTrackSourceStamp(t *testing.T) {
+	source := newMessageTracker().newSource()
+	headers := amqp.Table{"tenant": "a"}
+	stamped := source.stamp(headers, 3)
+	stamped = source.stamp(stamped, 4)
+	if stamped[producerHeader] != source.id || stamped[seqHeader] != int64(2) || stamped[channelHeader] != int64(4) || stamped["tenant"] != "a" {
+		t.Errorf("stamped headers %v", stamped)
+	}
+	if len(headers) != 1 {
+		t.Error("headers of the message modified")
+	}
+}
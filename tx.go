@@ -0,0 +1,61 @@
+package k9amqp
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Transaction is a channel handle in transactional mode pinned for the transaction callback.
+type Transaction struct {
+	*Channel
+}
+
+type TransactionFunc func(tx *Transaction) error
+
+// Transaction runs the callback in AMQP transaction, work of the callback is committed when it returns
+// and rolled back when it throws. Transactional channel is closed afterwards, it can't be reused by
+// non transactional operations.
+func (client *Client) Transaction(fn TransactionFunc) error {
+	channel, err := client.pin(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := channel.Close(); closeErr != nil {
+			slog.Debug("failed to close transactional channel", "error", closeErr)
+		}
+	}()
+	if err = channel.channel.Tx(); err != nil {
+		return err
+	}
+	channel.channel.transactional = true
+	tx := &Transaction{Channel: channel}
+	if err = fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			slog.Error("failed to rollback transaction", "error", rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Commit commits the work done since the transaction started or since the previous commit or rollback.
+func (tx *Transaction) Commit() error {
+	if tx.closed {
+		return errChannelClosed
+	}
+	startTime := time.Now()
+	err := tx.channel.TxCommit()
+	tx.client.k9amqp.reportTxMetrics(tx.channel.endpoint, time.Since(startTime), err, false)
+	return err
+}
+
+// Rollback discards the work done since the transaction started or since the previous commit or rollback.
+func (tx *Transaction) Rollback() error {
+	if tx.closed {
+		return errChannelClosed
+	}
+	err := tx.channel.TxRollback()
+	tx.client.k9amqp.reportTxMetrics(tx.channel.endpoint, 0, err, true)
+	return err
+}
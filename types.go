@@ -1,19 +1,89 @@
 package k9amqp
 
-import amqp "github.com/rabbitmq/amqp091-go"
+import (
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
 
 type (
 	AmqpOptions struct {
-		Host     string
-		Port     int
-		Vhost    string
-		Username string
-		Password string
+		Name             string
+		URI              string   `js:"uri"`
+		URIs             []string `js:"uris"`
+		Nodes            []NodeOptions
+		Host             string
+		Port             int
+		Vhost            string
+		Username         string
+		Password         string
+		TLS              TLSOptions `js:"tls"`
+		Auth             AuthOptions
+		Heartbeat        string
+		FrameMax         int
+		ChannelMax       int
+		DialTimeout      string
+		Locale           string
+		ConnectionName   string
+		ClientProperties amqp.Table
+		endpoints        []amqpEndpoint
+		heartbeat        time.Duration
+		dialTimeout      time.Duration
+	}
+
+	NodeOptions struct {
+		URI    string `js:"uri"`
+		Host   string
+		Port   int
+		Weight int
+	}
+
+	AuthOptions struct {
+		Mechanism string
+		Params    map[string]string
+	}
+
+	TLSOptions struct {
+		Enabled            bool
+		CaFile             string
+		CertFile           string
+		KeyFile            string
+		ServerName         string
+		MinVersion         string
+		InsecureSkipVerify bool
 	}
 
 	PoolOptions struct {
 		ChannelsPerConn   int
 		ChannelsCacheSize int
+		Connections       int
+		Distribution      string
+		Recovery          RecoveryOptions
+		MaxChannels       int
+		AcquireTimeout    string
+		WarmUp            int
+		MaxChannelAge     string
+		IdleTimeout       string
+		RotateInterval    string
+		Mode              string
+		Confirm           bool
+		AsyncConfirm      bool
+		ConfirmWindow     int
+		acquireTimeout    time.Duration
+		maxChannelAge     time.Duration
+		idleTimeout       time.Duration
+		rotateInterval    time.Duration
+	}
+
+	RecoveryOptions struct {
+		Disabled        bool
+		InitialInterval string
+		MaxInterval     string
+		Multiplier      float64
+		MaxAttempts     int
+		Topology        bool
+		initialInterval time.Duration
+		maxInterval     time.Duration
 	}
 
 	Queue struct {
@@ -75,6 +145,28 @@ type (
 	PublishOptions struct {
 		Exchange, Key        string
 		Mandatory, Immediate bool
+		Timeout              string
+		Confirm              bool
+		Body                 *BodyGenerator
+		Template             bool
+		Timestamp            bool
+		Track                bool
+		timeout              time.Duration
+		source               *trackSource
+		origin               *vuOrigin
+	}
+
+	BodyOptions struct {
+		Kind         string
+		Size         int
+		Distribution string
+		Min          int
+		Max          int
+		Mean         float64
+		StdDev       float64
+		Histogram    string
+		Seed         int64
+		File         string
 	}
 
 	GetOptions struct {
@@ -95,6 +187,41 @@ type (
 	AmqpProduceResponse struct {
 		Error        bool
 		ErrorMessage string
+		Confirmed    bool
+		Acked        bool
+		Returned     bool
+	}
+
+	BatchMessage struct {
+		amqp.Publishing
+		Exchange *string
+		Key      *string
+	}
+
+	AmqpBatchResponse struct {
+		Error        bool
+		ErrorMessage string
+		Sent         int
+		Failed       int
+		Nacked       int
+		Returned     int
+		Results      []AmqpProduceResponse
+	}
+
+	ProducerOptions struct {
+		PublishOptions
+		Profile       string
+		Rate          float64
+		TargetRate    float64
+		Step          float64
+		Amplitude     float64
+		BurstRate     float64
+		Duration      string
+		Period        string
+		BurstDuration string
+		duration      time.Duration
+		period        time.Duration
+		burstDuration time.Duration
 	}
 
 	AmqpGetResponse struct {
@@ -0,0 +1,44 @@
+package k9amqp
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// amqpEndpoint is a broker node the client is able to dial.
+type amqpEndpoint struct {
+	rawURI   string
+	uri      amqp.URI
+	frameMax int
+	weight   int
+	name     string
+}
+
+// newAmqpEndpoint parses AMQP URI, query parameters not handled by amqp.ParseURI are parsed here.
+func newAmqpEndpoint(rawURI string) (amqpEndpoint, error) {
+	uri, err := amqp.ParseURI(rawURI)
+	if err != nil {
+		return amqpEndpoint{}, fmt.Errorf("invalid amqp uri: %w", err)
+	}
+	endpoint := amqpEndpoint{rawURI: rawURI, uri: uri, weight: 1}
+	endpoint.name = fmt.Sprintf("%s://%s@%s:%d/%s", uri.Scheme, uri.Username, uri.Host, uri.Port, uri.Vhost)
+	u, err := url.Parse(rawURI)
+	if err != nil {
+		return amqpEndpoint{}, fmt.Errorf("invalid amqp uri: %w", err)
+	}
+	if frameMax := u.Query().Get("frame_max"); frameMax != "" {
+		endpoint.frameMax, err = strconv.Atoi(frameMax)
+		if err != nil {
+			return amqpEndpoint{}, fmt.Errorf("frame_max is not an integer: %w", err)
+		}
+	}
+	return endpoint, nil
+}
+
+// String identifies the node in logs and metric tags, password is not part of it.
+func (endpoint amqpEndpoint) String() string {
+	return endpoint.name
+}